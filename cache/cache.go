@@ -1,26 +1,126 @@
 package cache
 
 import (
+	"context"
+	"errors"
 	"time"
 )
 
+// ErrNotFound 缓存数据不存在或已过期
+var ErrNotFound = errors.New("缓存数据不存在或已过期")
+
+// NoExpiration TTL 返回该值表示数据永不过期
+const NoExpiration time.Duration = -1
+
 type Cache interface {
 	Get(key string, value any) error                       // 从缓存中获取数据
 	Set(key string, value any, expire time.Duration) error // 将数据存入缓存
 	Delete(key string) error                               // 从缓存中删除数据
 }
 
+// Store 带 context 的缓存接口，数据不存在时统一返回 ErrNotFound
+type Store interface {
+	GetWithContext(ctx context.Context, key string, value any) error                       // 从缓存中获取数据
+	SetWithContext(ctx context.Context, key string, value any, expire time.Duration) error // 将数据存入缓存
+	DeleteWithContext(ctx context.Context, key string) error                               // 从缓存中删除数据
+	Exists(ctx context.Context, key string) (bool, error)                                  // 判断数据是否存在
+	TTL(ctx context.Context, key string) (time.Duration, error)                            // 剩余有效期，永不过期返回 NoExpiration
+	Expire(ctx context.Context, key string, expire time.Duration) error                    // 重新设置有效期，expire<=0 表示永不过期
+	SetNX(ctx context.Context, key string, value any, expire time.Duration) (bool, error)  // 数据不存在时才写入，返回是否写入成功
+	Incr(ctx context.Context, key string, delta int64) (int64, error)                      // 原子自增，数据不存在时从 0 开始
+	Decr(ctx context.Context, key string, delta int64) (int64, error)                      // 原子自减，数据不存在时从 0 开始
+	GetDel(ctx context.Context, key string, value any) error                               // 原子地获取并删除数据
+}
+
 type Config struct {
-	Use   string
-	File  *FileConfig
-	Redis *RedisConfig
+	Use               string // 缓存类型：file、redis、memory、tiered，默认 file
+	Codec             string // 序列化方式：json、gob、msgpack，默认 json
+	Compress          string // 压缩算法：gzip、zstd，为空不压缩
+	CompressThreshold int    // 序列化后达到该字节数才压缩，默认 1024
+	Prefix            string // Redis 键前缀，对 redis 和 tiered 生效，文件与内存缓存不与其他服务共享无需前缀
+	File              *FileConfig
+	Redis             *RedisConfig
+	Memory            *MemoryConfig
+	Tiered            *TieredConfig // tiered 使用 Redis 作为二级缓存
 }
 
+// New 根据配置创建缓存，不检查 Redis 连接，Redis 配置错误时在操作时返回错误，需要启动时发现错误请使用 Open
 func New(config *Config) Cache {
+	c, _ := newCache(config, func(config *RedisConfig) (*Redis, error) {
+		return NewRedis(config), nil
+	})
+	return c
+}
+
+// Open 与 New 相同，但会立即连接 Redis，配置或连接错误时返回错误
+func Open(config *Config) (Cache, error) {
+	return newCache(config, OpenRedis)
+}
+
+// NewStore 与 New 相同，但返回带 context 的 Store 接口
+func NewStore(config *Config) Store {
+	return New(config).(Store)
+}
+
+// OpenStore 与 Open 相同，但返回带 context 的 Store 接口
+func OpenStore(config *Config) (Store, error) {
+	c, err := Open(config)
+	if err != nil {
+		return nil, err
+	}
+	return c.(Store), nil
+}
+
+func newCache(config *Config, newRedis func(*RedisConfig) (*Redis, error)) (Cache, error) {
+	codec := NewCodec(config.Codec, config.Compress, config.CompressThreshold)
 	switch config.Use {
 	case "redis":
-		return NewRedis(config.Redis)
+		r, err := newRedis(config.Redis)
+		if err != nil {
+			return nil, err
+		}
+		r.Codec = codec
+		r.Prefix = config.Prefix
+		return r, nil
+	case "memory":
+		m := NewMemory(config.Memory)
+		m.Codec = codec
+		return m, nil
+	case "tiered":
+		r, err := newRedis(config.Redis)
+		if err != nil {
+			return nil, err
+		}
+		r.Codec = codec
+		r.Prefix = config.Prefix
+		return NewTiered(r, config.Tiered), nil
 	default:
-		return NewFile(config.File)
+		f := NewFile(config.File)
+		f.Codec = codec
+		return f, nil
+	}
+}
+
+// Legacy 将 Store 适配为不带 context 的旧 Cache 接口
+func Legacy(s Store) Cache {
+	if c, ok := s.(Cache); ok {
+		return c
 	}
+	return &legacy{store: s}
+}
+
+type legacy struct {
+	store Store
+}
+
+func (l *legacy) Get(key string, value any) error {
+	return l.store.GetWithContext(context.Background(), key, value)
+}
+
+func (l *legacy) Set(key string, value any, expire time.Duration) error {
+	return l.store.SetWithContext(context.Background(), key, value, expire)
+}
+
+func (l *legacy) Delete(key string) error {
+	return l.store.DeleteWithContext(context.Background(), key)
 }
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestOpenRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, err := Open(&Config{Use: "redis", Prefix: "app:", Redis: &RedisConfig{Addr: mr.Addr()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("k", 1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if !mr.Exists("app:k") {
+		t.Fatal("键前缀没有生效")
+	}
+
+	if _, err := Open(&Config{Use: "redis", Redis: &RedisConfig{Mode: "unknown"}}); err == nil {
+		t.Fatal("不支持的部署模式应当返回错误")
+	}
+	addr := mr.Addr()
+	mr.Close()
+	if _, err := Open(&Config{Use: "redis", Redis: &RedisConfig{Addr: addr, DialTimeout: 100 * time.Millisecond}}); err == nil {
+		t.Fatal("无法连接时应当返回错误")
+	}
+}
+
+func TestNewDoesNotConnect(t *testing.T) {
+	// 与旧版本一致，New 不检查连接
+	c := New(&Config{Use: "redis", Redis: &RedisConfig{Addr: "127.0.0.1:1"}})
+	if _, ok := c.(*Redis); !ok {
+		t.Fatalf("New 返回了 %T", c)
+	}
+
+	// 配置错误时 New 不会 panic，错误在操作时返回
+	c = New(&Config{Use: "redis", Redis: &RedisConfig{Mode: "sentinel"}})
+	err := c.Set("k", 1, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "MasterName") {
+		t.Fatalf("配置错误时操作应当返回配置错误，实际为 %v", err)
+	}
+	var v int
+	if err := c.Get("k", &v); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("配置错误时读取应当返回配置错误，实际为 %v", err)
+	}
+	r := NewRedis(&RedisConfig{TLS: &RedisTLSConfig{CAFile: "missing.pem"}})
+	if err := r.Ping(context.Background()); err == nil {
+		t.Fatal("证书无法读取时 Ping 应当返回错误")
+	}
+	if _, err := r.Client.Pipelined(context.Background(), func(p redis.Pipeliner) error {
+		p.Set(context.Background(), "k", 1, 0)
+		return nil
+	}); err == nil {
+		t.Fatal("证书无法读取时管道命令应当返回错误")
+	}
+}
@@ -1,33 +1,97 @@
 package cache
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Client 按 id 存取临时数据，键的格式为 [命名空间:][v版本号:][租户:]client_key_id。
+// 未设置任何选项时与旧版本的键完全一致
 type Client struct {
-	Cache Cache
+	Cache     Cache
+	Namespace string // 命名空间，通常为应用或功能名称，多个服务共用同一个缓存时避免冲突
+	Tenant    string // 租户ID，为空表示不区分租户
+	Versioned bool   // 为命名空间维护版本号，Flush 时版本号加一，旧数据随之失效；每次读写多一次版本号查询，Flush 需要后端实现 Store
+	MaxKeyLen int    // 键超过该长度时对命名空间之后的部分取 SHA-256，0 表示不限制
 }
 
-func NewClient(cache Cache) *Client {
-	return &Client{
+var errNoVersion = errors.New("当前缓存不支持版本号")
+
+type ClientOption func(*Client)
+
+func WithNamespace(namespace string) ClientOption {
+	return func(c *Client) {
+		c.Namespace = namespace
+	}
+}
+
+func WithTenant(tenant string) ClientOption {
+	return func(c *Client) {
+		c.Tenant = tenant
+	}
+}
+
+func WithVersioning() ClientOption {
+	return func(c *Client) {
+		c.Versioned = true
+	}
+}
+
+func WithKeyHash(maxLen int) ClientOption {
+	return func(c *Client) {
+		c.MaxKeyLen = maxLen
+	}
+}
+
+func NewClient(cache Cache, opts ...ClientOption) *Client {
+	c := &Client{
 		Cache: cache,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ForTenant 返回共用同一缓存和命名空间、只属于指定租户的 Client
+func (c *Client) ForTenant(tenant string) *Client {
+	t := *c
+	t.Tenant = tenant
+	return &t
 }
 
 func (c *Client) Set(key string, v any, expir time.Duration) (string, error) {
 	id := uuid.New().String()
-	err := c.Cache.Set(c.getKey(id, key), v, expir)
-	return id, err
+	fullKey, err := c.getKey(context.Background(), id, key)
+	if err != nil {
+		return "", err
+	}
+	return id, c.Cache.Set(fullKey, v, expir)
 }
 
 func (c *Client) Get(id string, key string, value any) error {
-	return c.Cache.Get(c.getKey(id, key), value)
+	fullKey, err := c.getKey(context.Background(), id, key)
+	if err != nil {
+		return err
+	}
+	return c.Cache.Get(fullKey, value)
 }
 
+// GetAndDelete 获取并删除数据，后端实现了 Store 时为原子操作，同一数据只能被成功取出一次
 func (c *Client) GetAndDelete(id string, key string, value any) error {
-	fullKey := c.getKey(id, key)
+	fullKey, err := c.getKey(context.Background(), id, key)
+	if err != nil {
+		return err
+	}
+	if s, ok := c.Cache.(Store); ok {
+		return s.GetDel(context.Background(), fullKey, value)
+	}
 	if err := c.Cache.Get(fullKey, value); err != nil {
 		return err
 	}
@@ -35,9 +99,165 @@ func (c *Client) GetAndDelete(id string, key string, value any) error {
 }
 
 func (c *Client) Delete(id string, key string) error {
-	return c.Cache.Delete(c.getKey(id, key))
+	fullKey, err := c.getKey(context.Background(), id, key)
+	if err != nil {
+		return err
+	}
+	return c.Cache.Delete(fullKey)
+}
+
+// SetNX 数据不存在时写入，返回是否写入成功，需要后端实现 Store
+func (c *Client) SetNX(ctx context.Context, id string, key string, v any, expire time.Duration) (bool, error) {
+	s, ok := c.Cache.(Store)
+	if !ok {
+		return false, errUnsupported
+	}
+	fullKey, err := c.getKey(ctx, id, key)
+	if err != nil {
+		return false, err
+	}
+	return s.SetNX(ctx, fullKey, v, expire)
+}
+
+// Incr 将计数器加 delta 并返回新值，计数器首次创建时有效期为 expire，需要后端实现 Store。
+// 内置后端在同一个原子操作中设置有效期，不会留下永不过期的计数器
+func (c *Client) Incr(ctx context.Context, id string, key string, delta int64, expire time.Duration) (int64, error) {
+	s, ok := c.Cache.(Store)
+	if !ok {
+		return 0, errUnsupported
+	}
+	fullKey, err := c.getKey(ctx, id, key)
+	if err != nil {
+		return 0, err
+	}
+	return incrExpire(ctx, s, fullKey, delta, expire)
+}
+
+// Flush 使命名空间内的数据全部失效。
+// 开启版本号时只将版本号加一，旧数据不再被读取并随有效期自然过期；
+// 否则按前缀删除，设置了租户时只删除该租户的数据，需要后端实现 Tagger
+func (c *Client) Flush(ctx context.Context) error {
+	if c.Versioned {
+		s, ok := c.Cache.(Store)
+		if !ok {
+			return errNoVersion
+		}
+		_, err := s.Incr(ctx, c.versionKey(), 1)
+		return err
+	}
+	t, ok := c.Cache.(Tagger)
+	if !ok {
+		return errUnsupported
+	}
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return err
+	}
+	if prefix == "" {
+		return errors.New("未设置命名空间或租户，不能清空缓存")
+	}
+	return t.DeletePrefix(ctx, prefix)
+}
+
+// Version 返回命名空间当前的版本号，未开启版本号时为 0。只读取不写入，版本号只在 Flush 时创建
+func (c *Client) Version(ctx context.Context) (int64, error) {
+	if !c.Versioned {
+		return 0, nil
+	}
+	return counter(ctx, c.Cache, c.versionKey())
 }
 
-func (c *Client) getKey(id string, key string) string {
-	return "client_" + key + "_" + id
+// Counter 读取 Incr 维护的计数器，不存在时返回 0，只读取不写入，不需要后端实现 Store
+func (c *Client) Counter(ctx context.Context, id string, key string) (int64, error) {
+	fullKey, err := c.getKey(ctx, id, key)
+	if err != nil {
+		return 0, err
+	}
+	return counter(ctx, c.Cache, fullKey)
+}
+
+func (c *Client) getKey(ctx context.Context, id string, key string) (string, error) {
+	prefix, err := c.prefix(ctx)
+	if err != nil {
+		return "", err
+	}
+	rest := "client_" + key + "_" + id
+	if c.MaxKeyLen > 0 && len(prefix)+len(rest) > c.MaxKeyLen {
+		sum := sha256.Sum256([]byte(rest))
+		rest = "h_" + hex.EncodeToString(sum[:])
+	}
+	return prefix + rest, nil
+}
+
+// prefix 返回命名空间、版本号和租户组成的键前缀
+func (c *Client) prefix(ctx context.Context) (string, error) {
+	var prefix string
+	if c.Namespace != "" {
+		prefix = c.Namespace + ":"
+	}
+	if c.Versioned {
+		version, err := c.Version(ctx)
+		if err != nil {
+			return "", err
+		}
+		prefix += "v" + strconv.FormatInt(version, 10) + ":"
+	}
+	if c.Tenant != "" {
+		prefix += c.Tenant + ":"
+	}
+	return prefix, nil
+}
+
+func (c *Client) versionKey() string {
+	return c.Namespace + ":client_version"
+}
+
+// counterReader 计数器不经过 Codec 保存的后端，例如 Redis 的 INCRBY
+type counterReader interface {
+	counter(ctx context.Context, key string) (int64, error)
+}
+
+// counter 读取 Incr 写入的计数器，不存在时返回 0
+func counter(ctx context.Context, c Cache, key string) (int64, error) {
+	for inner := c; ; {
+		if r, ok := inner.(counterReader); ok {
+			return r.counter(ctx, key)
+		}
+		w, ok := inner.(interface{ Unwrap() Cache })
+		if !ok {
+			break
+		}
+		inner = w.Unwrap()
+	}
+	var n int64
+	if err := getContext(ctx, c, key, &n); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return n, nil
+}
+
+// expiringCounter 可以在自增的同时原子地设置有效期的后端
+type expiringCounter interface {
+	incrExpire(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error)
+}
+
+// incrExpire 自增计数器，计数器没有有效期时（首次创建）设置为 expire，
+// 后端不支持原子操作时退回为 Incr 之后再 Expire
+func incrExpire(ctx context.Context, s Store, key string, delta int64, expire time.Duration) (int64, error) {
+	if c, ok := s.(expiringCounter); ok {
+		return c.incrExpire(ctx, key, delta, expire)
+	}
+	n, err := s.Incr(ctx, key, delta)
+	if err != nil {
+		return 0, err
+	}
+	if n == delta && expire > 0 {
+		if err := s.Expire(ctx, key, expire); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
 }
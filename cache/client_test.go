@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestClientVersionReadOnly(t *testing.T) {
+	ctx := context.Background()
+	stores := testStores(t)
+	// 计数器不经过 Codec，非 json 序列化时同样可以读取
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	stores["redis-msgpack"] = &Redis{Client: client, Codec: NewCodec("msgpack", "gzip", 1)}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			c := NewClient(store.(Cache), WithNamespace("ns"), WithVersioning())
+			var v string
+			if err := c.Get("id", "k", &v); !errors.Is(err, ErrNotFound) {
+				t.Fatal(err)
+			}
+			if ok, _ := store.Exists(ctx, c.versionKey()); ok {
+				t.Fatal("读取数据不应写入版本号")
+			}
+
+			id, err := c.Set("k", "v1", time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Flush(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if version, err := c.Version(ctx); err != nil || version != 1 {
+				t.Fatalf("Version = %d, %v", version, err)
+			}
+			if err := c.Get(id, "k", &v); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Flush 后旧数据应当失效，实际为 %q, %v", v, err)
+			}
+		})
+	}
+}
+
+func TestClientCounter(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := NewClient(store.(Cache))
+			if n, err := c.Counter(ctx, "id", "n"); err != nil || n != 0 {
+				t.Fatalf("Counter = %d, %v", n, err)
+			}
+			c.Incr(ctx, "id", "n", 2, time.Minute)
+			if n, err := c.Counter(ctx, "id", "n"); err != nil || n != 2 {
+				t.Fatalf("Counter = %d, %v", n, err)
+			}
+		})
+	}
+}
+
+func TestClientIncrExpire(t *testing.T) {
+	ctx := context.Background()
+	stores := testStores(t)
+	stores["metrics"] = NewMetrics("test", NewMemory(nil)).Wrapped().(Store)
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			c := NewClient(store.(Cache))
+			for i := range 3 {
+				n, err := c.Incr(ctx, "id", "n", 1, time.Minute)
+				if err != nil || n != int64(i+1) {
+					t.Fatalf("Incr = %d, %v", n, err)
+				}
+			}
+			key, _ := c.getKey(ctx, "id", "n")
+			if ttl, err := store.TTL(ctx, key); err != nil || ttl <= 0 || ttl > time.Minute {
+				t.Fatalf("计数器的有效期为 %v, %v，应当为首次创建时的 expire", ttl, err)
+			}
+
+			// 没有有效期的计数器在下次自增时补上有效期
+			if err := store.SetWithContext(ctx, "plain", 5, 0); err != nil {
+				t.Fatal(err)
+			}
+			counter, ok := store.(expiringCounter)
+			if !ok {
+				t.Fatalf("%T 应当支持自增时原子设置有效期", store)
+			}
+			if n, err := counter.incrExpire(ctx, "plain", 1, time.Minute); err != nil || n != 6 {
+				t.Fatalf("incrExpire = %d, %v", n, err)
+			}
+			if ttl, err := store.TTL(ctx, "plain"); err != nil || ttl <= 0 {
+				t.Fatalf("计数器的有效期为 %v, %v，应当补上有效期", ttl, err)
+			}
+		})
+	}
+}
+
+func TestClientKeyUsesContext(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	c := NewClient(&Redis{Client: client}, WithNamespace("ns"), WithVersioning())
+
+	// 版本号查询使用调用方的 ctx
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Counter(ctx, "id", "n"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ctx 取消后应当返回 context.Canceled，实际为 %v", err)
+	}
+	if _, err := c.Incr(ctx, "id", "n", 1, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ctx 取消后应当返回 context.Canceled，实际为 %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec 缓存数据的序列化方式。
+// 注意 Incr/Decr 在 Redis 上使用原生命令，计数器以十进制文本保存，只有 JSON 能直接读取
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// NewCodec 根据名称创建序列化方式：json、gob、msgpack，默认 json；
+// compress 为 gzip 或 zstd 时，序列化结果不小于 threshold 字节的数据会被压缩
+func NewCodec(name string, compress string, threshold int) Codec {
+	var codec Codec
+	switch name {
+	case "gob":
+		codec = GobCodec{}
+	case "msgpack":
+		codec = MsgpackCodec{}
+	default:
+		codec = JSONCodec{}
+	}
+	if compress == "" {
+		return codec
+	}
+	return &CompressCodec{Codec: codec, Algorithm: compress, Threshold: threshold}
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec 使用 encoding/gob，接口类型的值需要提前 gob.Register
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// CompressCodec 在 Codec 之上透明压缩较大的数据。
+// 解压时根据 gzip/zstd 的魔数识别，未压缩的数据以及修改配置前写入的数据都能正常读取
+type CompressCodec struct {
+	Codec     Codec
+	Algorithm string // gzip 或 zstd
+	Threshold int    // 达到该字节数才压缩，默认 1024
+}
+
+func (c *CompressCodec) Marshal(v any) ([]byte, error) {
+	data, err := c.Codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	threshold := c.Threshold
+	if threshold <= 0 {
+		threshold = 1024
+	}
+	if len(data) < threshold {
+		return data, nil
+	}
+
+	switch c.Algorithm {
+	case "gzip":
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "zstd":
+		return zstdEncoder.EncodeAll(data, nil), nil
+	}
+	return data, nil
+}
+
+func (c *CompressCodec) Unmarshal(data []byte, v any) error {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if data, err = io.ReadAll(r); err != nil {
+			return err
+		}
+	case bytes.HasPrefix(data, zstdMagic):
+		var err error
+		if data, err = zstdDecoder.DecodeAll(data, nil); err != nil {
+			return err
+		}
+	}
+	return c.Codec.Unmarshal(data, v)
+}
+
+// zstd 的编解码器可以并发复用 EncodeAll/DecodeAll
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// codecOf 未设置 Codec 时使用 JSON
+func codecOf(c Codec) Codec {
+	if c == nil {
+		return JSONCodec{}
+	}
+	return c
+}
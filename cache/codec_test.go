@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type codecValue struct {
+	Name  string
+	Count int
+	Tags  []string
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	value := codecValue{Name: strings.Repeat("goweb", 500), Count: 42, Tags: []string{"a", "b"}}
+	for _, name := range []string{"json", "gob", "msgpack"} {
+		for _, compress := range []string{"", "gzip", "zstd"} {
+			label := compress
+			if label == "" {
+				label = "none"
+			}
+			t.Run(name+"/"+label, func(t *testing.T) {
+				codec := NewCodec(name, compress, 0)
+				data, err := codec.Marshal(value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				switch compress {
+				case "gzip":
+					if !bytes.HasPrefix(data, gzipMagic) {
+						t.Fatal("超过阈值的数据应当使用 gzip 压缩")
+					}
+				case "zstd":
+					if !bytes.HasPrefix(data, zstdMagic) {
+						t.Fatal("超过阈值的数据应当使用 zstd 压缩")
+					}
+				}
+
+				var got codecValue
+				if err := codec.Unmarshal(data, &got); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, value) {
+					t.Fatalf("反序列化结果为 %+v，应当为 %+v", got, value)
+				}
+			})
+		}
+	}
+}
+
+func TestCompressThreshold(t *testing.T) {
+	const threshold = 64
+	tests := []struct {
+		name     string
+		size     int // JSON 序列化后的字节数
+		compress bool
+	}{
+		{"低于阈值", threshold - 1, false},
+		{"等于阈值", threshold, true},
+		{"高于阈值", threshold + 1, true},
+	}
+	for _, algorithm := range []string{"gzip", "zstd"} {
+		codec := NewCodec("json", algorithm, threshold)
+		for _, tt := range tests {
+			t.Run(algorithm+"/"+tt.name, func(t *testing.T) {
+				// 字符串序列化后带两个引号
+				value := strings.Repeat("a", tt.size-2)
+				data, err := codec.Marshal(value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				compressed := bytes.HasPrefix(data, gzipMagic) || bytes.HasPrefix(data, zstdMagic)
+				if compressed != tt.compress {
+					t.Fatalf("%d 字节的数据压缩: %v，应当为 %v", tt.size, compressed, tt.compress)
+				}
+				var got string
+				if err := codec.Unmarshal(data, &got); err != nil || got != value {
+					t.Fatalf("反序列化失败: %v", err)
+				}
+			})
+		}
+	}
+}
+
+// 未压缩的数据（低于阈值或开启压缩前写入）不带魔数，应当原样交给内层 Codec
+func TestCompressReadsUncompressed(t *testing.T) {
+	tests := []struct {
+		codec string
+		value any
+	}{
+		{"json", map[string]any{"name": "goweb"}},
+		{"json", "short"},
+		{"gob", codecValue{Name: "goweb", Count: 1}},
+		{"msgpack", codecValue{Name: "goweb", Count: 1}},
+		// msgpack 编码的 31 恰好是 gzip 魔数的第一个字节
+		{"msgpack", 31},
+	}
+	for _, tt := range tests {
+		for _, algorithm := range []string{"gzip", "zstd"} {
+			t.Run(tt.codec+"/"+algorithm, func(t *testing.T) {
+				data, err := NewCodec(tt.codec, "", 0).Marshal(tt.value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := reflect.New(reflect.TypeOf(tt.value))
+				if err := NewCodec(tt.codec, algorithm, 1<<20).Unmarshal(data, got.Interface()); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got.Elem().Interface(), tt.value) {
+					t.Fatalf("反序列化结果为 %v，应当为 %v", got.Elem().Interface(), tt.value)
+				}
+			})
+		}
+	}
+}
@@ -1,129 +1,770 @@
 ﻿package cache
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"hash/fnv"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gregjones/httpcache/diskcache"
+	"github.com/peterbourgon/diskv"
+)
+
+const (
+	fileStripes     = 64       // 锁分段数
+	fileIndexDir    = "expiry" // 过期索引目录，按过期时间分桶
+	fileIndexBucket = 60       // 过期索引分桶宽度，秒
 )
 
 type FileConfig struct {
-	Path string
+	Path          string
+	SweepInterval time.Duration // 过期数据清理间隔，默认 10 分钟
+	StatsInterval time.Duration // 磁盘占用统计间隔，默认 1 小时
 }
 
 type File struct {
-	mu     sync.RWMutex
-	Client *diskcache.Cache
-	Path   string
+	stripes [fileStripes]sync.RWMutex
+	Client  *diskcache.Cache
+	Path    string
+	Codec   Codec // 序列化方式，默认 JSON
+	files   *diskv.Diskv // Client 底层的存储，按文件名删除时使用以便同时清除其内存缓存
+	config  *FileConfig
+	disk    atomic.Pointer[DiskStats]
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
 }
 
 func NewFile(config *FileConfig) *File {
+	// 复制一份配置，默认值不写回调用方传入的结构
+	copied := *config
+	config = &copied
+	if config.SweepInterval <= 0 {
+		config.SweepInterval = 10 * time.Minute
+	}
+	if config.StatsInterval <= 0 {
+		config.StatsInterval = time.Hour
+	}
+	// 与 diskcache.New 的参数一致
+	files := diskv.New(diskv.Options{
+		BasePath:     config.Path,
+		CacheSizeMax: 100 * 1024 * 1024,
+	})
 	f := &File{
-		Client: diskcache.New(config.Path),
+		Client: diskcache.NewWithDiskv(files),
 		Path:   config.Path,
+		files:  files,
+		config: config,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
 	}
 	go f.startCleanup()
 	return f
 }
 
 type Value struct {
+	Key       string // 原始键，文件名是键的 md5，遍历目录时依靠它还原键
 	Expire    time.Time
 	HasExpire bool
-	Value     any
+	Value     any    `json:",omitempty"` // 旧版本以 JSON 保存的数据，仅用于兼容读取
+	Data      []byte `json:",omitempty"` // 使用 Codec 序列化后的数据
 }
 
-func (f *File) Get(key string, value any) error {
-	f.mu.RLock()
-	str, ok := f.Client.Get(key)
-	f.mu.RUnlock()
-	if !ok {
-		return errors.New("从文件缓存获取数据失败")
+// fileEntry 读取时使用，保留原始字节避免数字等类型在二次转换中失真
+type fileEntry struct {
+	Key       string
+	Expire    time.Time
+	HasExpire bool
+	Value     json.RawMessage
+	Data      []byte
+}
+
+// decode 反序列化数据，兼容旧版本写在 Value 中的 JSON
+func (e *fileEntry) decode(codec Codec, value any) error {
+	if e.Data == nil && e.Value != nil {
+		return json.Unmarshal(e.Value, value)
 	}
+	return codec.Unmarshal(e.Data, value)
+}
 
-	var val Value
-	if err := json.Unmarshal(str, &val); err != nil {
+// value 还原为写入时的结构，用于只修改过期时间的场景
+func (e *fileEntry) value(expire time.Time) Value {
+	val := Value{
+		Key:       e.Key,
+		Data:      e.Data,
+		HasExpire: !expire.IsZero(),
+		Expire:    expire,
+	}
+	if e.Data == nil && e.Value != nil {
+		val.Value = e.Value
+	}
+	return val
+}
+
+func (e *fileEntry) expired(now time.Time) bool {
+	return e.HasExpire && now.After(e.Expire)
+}
+
+func (f *File) Get(key string, value any) error {
+	return f.GetWithContext(context.Background(), key, value)
+}
+
+func (f *File) GetWithContext(ctx context.Context, key string, value any) error {
+	f.lock(key).RLock()
+	entry, err := f.read(key)
+	f.lock(key).RUnlock()
+	if err != nil {
 		return err
 	}
 
-	if val.HasExpire && time.Now().After(val.Expire) {
-		f.Delete(key)
-		return errors.New("缓存数据已过期")
+	if entry.expired(time.Now()) {
+		// 加写锁后重新读取，避免删除期间被其他调用写入的新数据
+		f.lock(key).Lock()
+		f.readLive(key)
+		f.lock(key).Unlock()
+		return ErrNotFound
 	}
+	return entry.decode(f.codec(), value)
+}
 
-	data, err := json.Marshal(val.Value)
+func (f *File) Set(key string, value any, expire time.Duration) error {
+	return f.SetWithContext(context.Background(), key, value, expire)
+}
+
+func (f *File) SetWithContext(ctx context.Context, key string, value any, expire time.Duration) error {
+	data, err := f.codec().Marshal(value)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, value)
+
+	f.lock(key).Lock()
+	defer f.lock(key).Unlock()
+	return f.write(key, data, expireAt(expire))
 }
 
-func (f *File) Set(key string, value any, expire time.Duration) error {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+func (f *File) Delete(key string) error {
+	return f.DeleteWithContext(context.Background(), key)
+}
 
-	val := Value{
-		Value: value,
+func (f *File) DeleteWithContext(ctx context.Context, key string) error {
+	f.lock(key).Lock()
+	defer f.lock(key).Unlock()
+	f.Client.Delete(key)
+	return nil
+}
+
+func (f *File) Exists(ctx context.Context, key string) (bool, error) {
+	f.lock(key).RLock()
+	defer f.lock(key).RUnlock()
+	entry, err := f.read(key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
 	}
-	if expire > 0 {
-		val.HasExpire = true
-		val.Expire = time.Now().Add(expire)
+	if err != nil {
+		return false, err
 	}
+	return !entry.expired(time.Now()), nil
+}
 
-	data, err := json.Marshal(val)
+func (f *File) TTL(ctx context.Context, key string) (time.Duration, error) {
+	f.lock(key).RLock()
+	defer f.lock(key).RUnlock()
+	entry, err := f.read(key)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	if entry.expired(now) {
+		return 0, ErrNotFound
+	}
+	if !entry.HasExpire {
+		return NoExpiration, nil
+	}
+	return entry.Expire.Sub(now), nil
+}
+
+func (f *File) Expire(ctx context.Context, key string, expire time.Duration) error {
+	f.lock(key).Lock()
+	defer f.lock(key).Unlock()
+	entry, err := f.readLive(key)
 	if err != nil {
 		return err
 	}
-	f.Client.Set(key, data)
-	return nil
+	return f.store(key, entry.value(expireAt(expire)))
 }
 
-func (f *File) Delete(key string) error {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+func (f *File) SetNX(ctx context.Context, key string, value any, expire time.Duration) (bool, error) {
+	data, err := f.codec().Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	f.lock(key).Lock()
+	defer f.lock(key).Unlock()
+	if _, err := f.readLive(key); err == nil {
+		return false, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+	return true, f.write(key, data, expireAt(expire))
+}
+
+func (f *File) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	return f.incrExpire(ctx, key, delta, 0)
+}
+
+// incrExpire 计数器没有有效期时设置为 ttl，与自增在同一把锁内完成
+func (f *File) incrExpire(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	f.lock(key).Lock()
+	defer f.lock(key).Unlock()
+
+	var (
+		n      int64
+		expire time.Time
+	)
+	entry, err := f.readLive(key)
+	switch {
+	case err == nil:
+		if err := entry.decode(f.codec(), &n); err != nil {
+			return 0, errors.New("缓存数据不是整数")
+		}
+		if entry.HasExpire {
+			expire = entry.Expire
+		}
+	case !errors.Is(err, ErrNotFound):
+		return 0, err
+	}
+	if expire.IsZero() {
+		expire = expireAt(ttl)
+	}
+
+	n += delta
+	data, err := f.codec().Marshal(n)
+	if err != nil {
+		return 0, err
+	}
+	return n, f.write(key, data, expire)
+}
+
+func (f *File) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return f.Incr(ctx, key, -delta)
+}
+
+func (f *File) GetDel(ctx context.Context, key string, value any) error {
+	f.lock(key).Lock()
+	defer f.lock(key).Unlock()
+	entry, err := f.readLive(key)
+	if err != nil {
+		return err
+	}
 	f.Client.Delete(key)
+	return entry.decode(f.codec(), value)
+}
+
+func (f *File) codec() Codec {
+	return codecOf(f.Codec)
+}
+
+// read 读取缓存条目（可能已过期），调用方需持有锁
+func (f *File) read(key string) (*fileEntry, error) {
+	str, ok := f.Client.Get(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var entry fileEntry
+	if err := json.Unmarshal(str, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// readLive 读取未过期的缓存条目，过期数据顺带删除，调用方需持有写锁
+func (f *File) readLive(key string) (*fileEntry, error) {
+	entry, err := f.read(key)
+	if err != nil {
+		return nil, err
+	}
+	if entry.expired(time.Now()) {
+		f.Client.Delete(key)
+		return nil, ErrNotFound
+	}
+	return entry, nil
+}
+
+// write 写入已序列化的数据，expire 为零值表示永不过期，调用方需持有写锁
+func (f *File) write(key string, data []byte, expire time.Time) error {
+	return f.store(key, Value{
+		Key:       key,
+		Data:      data,
+		HasExpire: !expire.IsZero(),
+		Expire:    expire,
+	})
+}
+
+// store 写入缓存条目，条目本身固定使用 JSON 保存，调用方需持有写锁
+func (f *File) store(key string, val Value) error {
+	str, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	f.Client.Set(key, str)
+	if val.HasExpire {
+		return f.index(key, val.Expire)
+	}
 	return nil
 }
 
 func (f *File) startCleanup() {
-	ticker := time.NewTicker(10 * time.Minute)
+	defer close(f.done)
+	ticker := time.NewTicker(f.config.SweepInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		f.cleanup()
+	// 索引目录不存在说明数据由旧版本写入，全量扫描一次建立过期索引
+	if _, err := os.Stat(filepath.Join(f.Path, fileIndexDir)); os.IsNotExist(err) {
+		f.rebuildIndex()
+	}
+	f.cleanup()
+	for {
+		select {
+		case <-ticker.C:
+			f.cleanup()
+		case <-f.stop:
+			return
+		}
 	}
 }
 
+// Close 停止后台清理，并等待正在进行的清理结束
+func (f *File) Close() error {
+	f.once.Do(func() {
+		close(f.stop)
+	})
+	<-f.done
+	return nil
+}
+
+// cleanup 只处理过期索引中已经到期的分桶，耗时与过期数据量成正比，未到期的分桶去除重复的键；
+// 磁盘占用统计只读取文件信息，按 StatsInterval 单独刷新
 func (f *File) cleanup() {
-	entries, err := os.ReadDir(f.Path)
+	now := time.Now()
+	dir := filepath.Join(f.Path, fileIndexDir)
+	buckets, err := os.ReadDir(dir)
+	if err != nil {
+		buckets = nil
+	}
+	for _, bucket := range buckets {
+		end, err := strconv.ParseInt(bucket.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		if end*fileIndexBucket > now.Unix() {
+			f.compactBucket(filepath.Join(dir, bucket.Name()))
+		} else {
+			f.sweepBucket(filepath.Join(dir, bucket.Name()))
+		}
+	}
+
+	if stats := f.disk.Load(); stats == nil || now.Sub(stats.UpdatedAt) >= f.config.StatsInterval {
+		f.refreshStats(now)
+	}
+}
+
+// sweepBucket 删除分桶中已过期的数据，数据被重新写入后会进入新的分桶，这里读取时仍未过期则保留。
+// 索引文件中的键都属于文件名对应的锁分段，持有该分段的锁处理整个文件，避免与追加索引并发
+func (f *File) sweepBucket(path string) {
+	f.eachIndex(path, func(name string, keys []string) {
+		for _, key := range keys {
+			f.readLive(key)
+		}
+		os.Remove(name)
+	})
+	os.Remove(path)
+}
+
+// compactBucket 同一个键多次写入时会重复追加索引，去重后重写索引文件
+func (f *File) compactBucket(path string) {
+	f.eachIndex(path, func(name string, keys []string) {
+		unique := slices.Compact(slices.Sorted(slices.Values(keys)))
+		if len(unique) == len(keys) {
+			return
+		}
+		var b strings.Builder
+		for _, key := range unique {
+			b.WriteString(strconv.Quote(key) + "\n")
+		}
+		tmp := name + ".tmp"
+		if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+			os.Remove(tmp)
+			return
+		}
+		os.Rename(tmp, name)
+	})
+}
+
+// eachIndex 逐个读取分桶中的索引文件，fn 执行期间持有文件对应锁分段的写锁
+func (f *File) eachIndex(path string, fn func(name string, keys []string)) {
+	files, err := os.ReadDir(path)
 	if err != nil {
 		return
 	}
+	for _, file := range files {
+		n, err := strconv.Atoi(file.Name())
+		if err != nil || n < 0 || n >= fileStripes {
+			continue
+		}
+		name := filepath.Join(path, file.Name())
+		f.stripes[n].Lock()
+		if data, err := os.ReadFile(name); err == nil {
+			var keys []string
+			for line := range strings.Lines(string(data)) {
+				if key, err := strconv.Unquote(strings.TrimSpace(line)); err == nil {
+					keys = append(keys, key)
+				}
+			}
+			fn(name, keys)
+		}
+		f.stripes[n].Unlock()
+	}
+}
+
+// rebuildIndex 全量扫描，删除过期数据并为未过期的数据建立索引
+func (f *File) rebuildIndex() {
+	now := time.Now()
+	f.walk(func(name string, size int64, entry *fileEntry) {
+		if entry.Key == "" {
+			// 旧版本写入的数据没有记录键，过期后按文件名删除
+			if entry.expired(now) {
+				f.files.Erase(name)
+			}
+			return
+		}
+		f.lock(entry.Key).Lock()
+		defer f.lock(entry.Key).Unlock()
+		if entry.expired(now) {
+			f.readLive(entry.Key)
+		} else if entry.HasExpire {
+			f.index(entry.Key, entry.Expire)
+		}
+	})
+	os.MkdirAll(filepath.Join(f.Path, fileIndexDir), 0755)
+}
+
+// index 将键追加到过期时间所在分桶中与键同一锁分段的索引文件，调用方需持有该键的写锁
+func (f *File) index(key string, expire time.Time) error {
+	bucket := (expire.Unix() + fileIndexBucket) / fileIndexBucket
+	dir := filepath.Join(f.Path, fileIndexDir, strconv.FormatInt(bucket, 10))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, strconv.Itoa(stripe(key))), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(strconv.Quote(key) + "\n")
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
 
+// refreshStats 统计数据文件的数量和大小，只读取目录信息而不读取文件内容
+func (f *File) refreshStats(now time.Time) {
+	entries, err := os.ReadDir(f.Path)
+	if err != nil {
+		return
+	}
+	stats := DiskStats{UpdatedAt: now}
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
 		}
+		if info, err := entry.Info(); err == nil {
+			stats.Entries++
+			stats.Bytes += info.Size()
+		}
+	}
+	f.disk.Store(&stats)
+}
+
+// DiskStats 最近一次统计的磁盘占用（包含已过期但尚未清理的数据），尚未统计时返回零值
+func (f *File) DiskStats() DiskStats {
+	if stats := f.disk.Load(); stats != nil {
+		return *stats
+	}
+	return DiskStats{}
+}
 
-		key := filepath.Base(entry.Name())
-		var val Value
+// lock 返回键所在的锁分段，不同分段的读写互不阻塞
+func (f *File) lock(key string) *sync.RWMutex {
+	return &f.stripes[stripe(key)]
+}
+
+func stripe(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % fileStripes)
+}
 
-		f.mu.RLock()
-		str, ok := f.Client.Get(key)
-		f.mu.RUnlock()
-		if !ok {
+// walk 遍历缓存目录下的所有数据文件，无法解析的文件会被跳过
+func (f *File) walk(fn func(name string, size int64, entry *fileEntry)) {
+	entries, err := os.ReadDir(f.Path)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
 			continue
 		}
-
+		str, err := os.ReadFile(filepath.Join(f.Path, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var val fileEntry
 		if err := json.Unmarshal(str, &val); err != nil {
 			continue
 		}
+		fn(entry.Name(), int64(len(str)), &val)
+	}
+}
+
+// SetWithTags 各个标签索引分别在自己的锁分段内更新，不会同时持有多个分段的锁
+func (f *File) SetWithTags(ctx context.Context, key string, value any, expire time.Duration, tags ...string) error {
+	data, err := f.codec().Marshal(value)
+	if err != nil {
+		return err
+	}
 
-		if val.HasExpire && time.Now().After(val.Expire) {
-			f.Delete(key)
+	at := expireAt(expire)
+	f.lock(key).Lock()
+	err = f.write(key, data, at)
+	f.lock(key).Unlock()
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := f.addTag(tagKey(tag), key, at); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// InvalidateTag 先删除标签关联的数据再从索引中移除已处理的键，
+// 删除中途失败时索引仍然保留，重试可以继续清理；并发加入索引的键不会丢失
+func (f *File) InvalidateTag(ctx context.Context, tag string) error {
+	f.lock(tagKey(tag)).RLock()
+	entry, err := f.read(tagKey(tag))
+	f.lock(tagKey(tag)).RUnlock()
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	if err := entry.decode(f.codec(), &keys); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		f.lock(key).Lock()
+		f.Client.Delete(key)
+		f.lock(key).Unlock()
+	}
+	return f.removeTag(tagKey(tag), keys)
+}
+
+func (f *File) DeletePrefix(ctx context.Context, prefix string) error {
+	f.walk(func(name string, size int64, entry *fileEntry) {
+		if entry.Key == "" || !strings.HasPrefix(entry.Key, prefix) {
+			return
+		}
+		f.lock(entry.Key).Lock()
+		f.Client.Delete(entry.Key)
+		f.lock(entry.Key).Unlock()
+	})
+	return nil
+}
+
+// addTag 将 key 加入标签索引，索引的过期时间取所有成员中最晚的一个
+func (f *File) addTag(tag string, key string, expire time.Time) error {
+	f.lock(tag).Lock()
+	defer f.lock(tag).Unlock()
+
+	var keys []string
+	if entry, err := f.readLive(tag); err == nil {
+		if err := entry.decode(f.codec(), &keys); err != nil {
+			return err
+		}
+		if !entry.HasExpire || (!expire.IsZero() && entry.Expire.After(expire)) {
+			expire = entry.Expire
+		}
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if !slices.Contains(keys, key) {
+		keys = append(keys, key)
+	}
+	data, err := f.codec().Marshal(keys)
+	if err != nil {
+		return err
+	}
+	return f.write(tag, data, expire)
+}
+
+// removeTag 从标签索引中移除 keys，索引为空时删除
+func (f *File) removeTag(tag string, keys []string) error {
+	f.lock(tag).Lock()
+	defer f.lock(tag).Unlock()
+
+	entry, err := f.readLive(tag)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var current []string
+	if err := entry.decode(f.codec(), &current); err != nil {
+		return err
+	}
+	current = slices.DeleteFunc(current, func(key string) bool {
+		return slices.Contains(keys, key)
+	})
+	if len(current) == 0 {
+		f.Client.Delete(tag)
+		return nil
+	}
+	data, err := f.codec().Marshal(current)
+	if err != nil {
+		return err
+	}
+	return f.write(tag, data, entry.Expire)
+}
+
+// expireAt 将有效期换算为过期时间点，expire<=0 返回零值表示永不过期
+func expireAt(expire time.Duration) time.Time {
+	if expire <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(expire)
+}
+
+// fileLock 锁文件内容
+type fileLock struct {
+	Token  string
+	Expire time.Time
+}
+
+// acquire 以 O_EXCL 创建锁文件，已过期的锁文件会被清除后重试一次。
+// 进程内由键所在的锁分段保证互斥，多个进程同时清除过期锁时仍可能竞争，因此只适用于单机
+func (f *File) acquire(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	f.lock(key).Lock()
+	defer f.lock(key).Unlock()
+
+	path, err := f.lockPath(key)
+	if err != nil {
+		return false, err
+	}
+	for range 2 {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			err = json.NewEncoder(file).Encode(fileLock{Token: token, Expire: time.Now().Add(ttl)})
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return false, err
+			}
+			return true, nil
+		}
+		if !os.IsExist(err) {
+			return false, err
+		}
+		// 内容无法解析时可能是其他进程刚创建还未写入，视为被占用
+		lock, err := readFileLock(path)
+		if err != nil || time.Now().Before(lock.Expire) {
+			return false, nil
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func (f *File) release(ctx context.Context, key string, token string) (bool, error) {
+	f.lock(key).Lock()
+	defer f.lock(key).Unlock()
+
+	path, err := f.lockPath(key)
+	if err != nil {
+		return false, err
+	}
+	if !holdsFileLock(path, token) {
+		return false, nil
+	}
+	return true, os.Remove(path)
+}
+
+func (f *File) extend(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	f.lock(key).Lock()
+	defer f.lock(key).Unlock()
+
+	path, err := f.lockPath(key)
+	if err != nil {
+		return false, err
+	}
+	if !holdsFileLock(path, token) {
+		return false, nil
+	}
+	// 先写临时文件再重命名，避免其他进程读到写了一半的内容
+	data, err := json.Marshal(fileLock{Token: token, Expire: time.Now().Add(ttl)})
+	if err != nil {
+		return false, err
+	}
+	tmp := path + "." + token
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return false, err
+	}
+	return true, os.Rename(tmp, path)
+}
+
+// lockPath 锁文件保存在缓存目录的 locks 子目录下，文件名为键的 md5
+func (f *File) lockPath(key string) (string, error) {
+	dir := filepath.Join(f.Path, "locks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	sum := md5.Sum([]byte(key))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".lock"), nil
+}
+
+func readFileLock(path string) (*fileLock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lock fileLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+func holdsFileLock(path string, token string) bool {
+	lock, err := readFileLock(path)
+	return err == nil && lock.Token == token && time.Now().Before(lock.Expire)
 }
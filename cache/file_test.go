@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestFile 停止后台清理，由测试手动触发，避免与断言并发
+func newTestFile(t *testing.T) *File {
+	t.Helper()
+	f := NewFile(&FileConfig{Path: t.TempDir()})
+	f.Close()
+	return f
+}
+
+// indexLines 返回过期索引中记录 key 的行数
+func indexLines(t *testing.T, f *File, key string) int {
+	t.Helper()
+	var n int
+	filepath.WalkDir(filepath.Join(f.Path, fileIndexDir), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		n += strings.Count(string(data), strconv.Quote(key)+"\n")
+		return nil
+	})
+	return n
+}
+
+func TestFileConfigNotModified(t *testing.T) {
+	config := &FileConfig{Path: t.TempDir()}
+	f := NewFile(config)
+	defer f.Close()
+	if config.SweepInterval != 0 || config.StatsInterval != 0 {
+		t.Fatalf("NewFile 修改了调用方的配置: %+v", config)
+	}
+}
+
+func TestFileClose(t *testing.T) {
+	f := NewFile(&FileConfig{Path: t.TempDir(), SweepInterval: 10 * time.Millisecond})
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("重复关闭应当直接返回: %v", err)
+	}
+
+	// 关闭后后台清理不再运行，过期数据保留到下次访问
+	if err := f.Set("k", 1, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := f.Client.Get("k"); !ok {
+		t.Fatal("关闭后不应当再清理过期数据")
+	}
+	var v int
+	if err := f.Get("k", &v); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("读取过期数据应当返回 ErrNotFound，实际为 %v", err)
+	}
+}
+
+func TestFileIndex(t *testing.T) {
+	f := newTestFile(t)
+
+	if err := f.Set("forever", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := indexLines(t, f, "forever"); n != 0 {
+		t.Fatalf("永不过期的数据不应当写入索引，实际 %d 行", n)
+	}
+
+	expire := time.Now().Add(time.Hour)
+	if err := f.Set("ttl", 1, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	bucket := strconv.FormatInt((expire.Unix()+fileIndexBucket)/fileIndexBucket, 10)
+	data, err := os.ReadFile(filepath.Join(f.Path, fileIndexDir, bucket, strconv.Itoa(stripe("ttl"))))
+	if err != nil {
+		t.Fatalf("过期索引应当写入过期时间所在的分桶: %v", err)
+	}
+	if string(data) != strconv.Quote("ttl")+"\n" {
+		t.Fatalf("索引内容为 %q", data)
+	}
+}
+
+func TestFileSweep(t *testing.T) {
+	f := newTestFile(t)
+
+	if err := f.Set("short", 1, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("long", 1, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	// 到期分桶按分钟对齐，直接把数据的过期时间改到之前的分桶
+	expire := time.Now().Add(-2 * fileIndexBucket * time.Second)
+	bucket := filepath.Join(f.Path, fileIndexDir, strconv.FormatInt((expire.Unix()+fileIndexBucket)/fileIndexBucket, 10))
+	f.lock("short").Lock()
+	entry, err := f.read("short")
+	if err == nil {
+		err = f.store("short", entry.value(expire))
+	}
+	f.lock("short").Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.cleanup()
+	if _, ok := f.Client.Get("short"); ok {
+		t.Fatal("过期数据应当从磁盘删除")
+	}
+	if _, err := os.Stat(bucket); !os.IsNotExist(err) {
+		t.Fatalf("已清理的分桶应当删除: %v", err)
+	}
+	var v int
+	if err := f.Get("long", &v); err != nil {
+		t.Fatalf("未过期的数据不应当被清理: %v", err)
+	}
+}
+
+func TestFileSweepCompactsIndex(t *testing.T) {
+	f := newTestFile(t)
+
+	for i := range 10 {
+		if err := f.Set("k", i, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := indexLines(t, f, "k"); n != 10 {
+		t.Fatalf("每次写入都会追加索引，实际 %d 行", n)
+	}
+	f.cleanup()
+	if n := indexLines(t, f, "k"); n != 1 {
+		t.Fatalf("清理后索引应当去重，实际 %d 行", n)
+	}
+	var v int
+	if err := f.Get("k", &v); err != nil || v != 9 {
+		t.Fatalf("去重不应当影响数据: %d, %v", v, err)
+	}
+}
+
+func TestFileRebuildIndexErasesLegacy(t *testing.T) {
+	f := newTestFile(t)
+
+	// 旧版本写入的数据没有记录键
+	data, err := json.Marshal(Value{Value: 1, HasExpire: true, Expire: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Client.Set("legacy", data)
+	if _, ok := f.Client.Get("legacy"); !ok {
+		t.Fatal("写入旧版本数据失败")
+	}
+
+	f.rebuildIndex()
+	if _, ok := f.Client.Get("legacy"); ok {
+		t.Fatal("重建索引应当删除过期的旧版本数据，包括内存中的缓存")
+	}
+}
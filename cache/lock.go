@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLocked   = errors.New("锁已被占用")
+	ErrLockLost = errors.New("锁已过期或已被其他持有者获取")
+
+	errLockTTL = errors.New("锁的有效期必须大于 0")
+)
+
+// lockBackend 锁的底层实现，token 用于确认锁仍由自己持有
+type lockBackend interface {
+	acquire(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+	release(ctx context.Context, key string, token string) (bool, error)
+	extend(ctx context.Context, key string, token string, ttl time.Duration) (bool, error)
+}
+
+// Locker 基于缓存的互斥锁。Redis 后端可用于多实例之间互斥，
+// File 与 Memory 后端只适用于单机
+type Locker struct {
+	backend       lockBackend
+	RetryInterval time.Duration // Lock 等待时的重试间隔，默认 100 毫秒
+}
+
+func NewLocker(c Cache) (*Locker, error) {
+	backend, ok := c.(lockBackend)
+	if !ok {
+		return nil, errors.New("当前缓存不支持分布式锁")
+	}
+	return &Locker{
+		backend:       backend,
+		RetryInterval: 100 * time.Millisecond,
+	}, nil
+}
+
+// TryLock 尝试获取锁，锁被占用时立即返回 ErrLocked，ttl 必须大于 0
+func (l *Locker) TryLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		return nil, errLockTTL
+	}
+	token := uuid.New().String()
+	ok, err := l.backend.acquire(ctx, lockKey(key), token, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLocked
+	}
+	return &Lock{Key: key, Token: token, ttl: ttl, locker: l}, nil
+}
+
+// Lock 获取锁，锁被占用时按 RetryInterval 重试直到成功或 ctx 结束，ttl 必须大于 0
+func (l *Locker) Lock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		return nil, errLockTTL
+	}
+	interval := l.RetryInterval
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		lock, err := l.TryLock(ctx, key, ttl)
+		if !errors.Is(err, ErrLocked) {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+type Lock struct {
+	Key    string
+	Token  string
+	ttl    time.Duration
+	locker *Locker
+	mu     sync.Mutex
+	stop   chan struct{}
+	done   chan struct{} // 看门狗退出后关闭
+}
+
+// Unlock 释放锁，锁已过期或被其他持有者获取时返回 ErrLockLost
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.stopRenew()
+	ok, err := l.locker.backend.release(ctx, lockKey(l.Key), l.Token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Extend 将锁的有效期重置为 ttl，ttl 必须大于 0
+func (l *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errLockTTL
+	}
+	ok, err := l.locker.backend.extend(ctx, lockKey(l.Key), l.Token, ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockLost
+	}
+	l.mu.Lock()
+	l.ttl = ttl
+	l.mu.Unlock()
+	return nil
+}
+
+// AutoRenew 启动看门狗，每隔 interval 将有效期续为获取锁时的 ttl，直到 Unlock。
+// interval<=0 时取 ttl 的三分之一；续期失败时看门狗退出并把错误发送到返回的通道
+func (l *Lock) AutoRenew(interval time.Duration) <-chan error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if interval <= 0 {
+		interval = l.ttl / 3
+	}
+	errs := make(chan error, 1)
+	if interval <= 0 {
+		errs <- errors.New("看门狗续期间隔必须大于 0")
+		close(errs)
+		return errs
+	}
+	if l.stop != nil {
+		close(errs)
+		return errs
+	}
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+
+	go func(stop chan struct{}, done chan struct{}) {
+		defer close(done)
+		defer close(errs)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				l.mu.Lock()
+				ttl := l.ttl
+				l.mu.Unlock()
+				ok, err := l.locker.backend.extend(context.Background(), lockKey(l.Key), l.Token, ttl)
+				if err == nil && !ok {
+					err = ErrLockLost
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}
+	}(l.stop, l.done)
+	return errs
+}
+
+// stopRenew 停止看门狗并等待正在进行的续期结束，避免释放锁之后又被续期
+func (l *Lock) stopRenew() {
+	l.mu.Lock()
+	stop, done := l.stop, l.done
+	l.stop, l.done = nil, nil
+	l.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+func lockKey(key string) string {
+	return "lock:" + key
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockExclusive(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			locker, err := NewLocker(store.(Cache))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lock, err := locker.TryLock(ctx, "job", time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := locker.TryLock(ctx, "job", time.Minute); !errors.Is(err, ErrLocked) {
+				t.Fatalf("重复加锁应当返回 ErrLocked，实际为 %v", err)
+			}
+
+			// 其他持有者的 token 不能释放锁
+			other := &Lock{Key: lock.Key, Token: "other", ttl: time.Minute, locker: locker}
+			if err := other.Unlock(ctx); !errors.Is(err, ErrLockLost) {
+				t.Fatalf("使用错误的 token 释放锁应当返回 ErrLockLost，实际为 %v", err)
+			}
+			if err := other.Extend(ctx, time.Minute); !errors.Is(err, ErrLockLost) {
+				t.Fatalf("使用错误的 token 续期应当返回 ErrLockLost，实际为 %v", err)
+			}
+
+			if err := lock.Unlock(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := lock.Unlock(ctx); !errors.Is(err, ErrLockLost) {
+				t.Fatalf("重复释放应当返回 ErrLockLost，实际为 %v", err)
+			}
+			lock, err = locker.TryLock(ctx, "job", time.Minute)
+			if err != nil {
+				t.Fatalf("释放后应当可以重新加锁: %v", err)
+			}
+			lock.Unlock(ctx)
+		})
+	}
+}
+
+func TestLockConcurrent(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			locker, _ := NewLocker(store.(Cache))
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			acquired := 0
+			for range 20 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := locker.TryLock(ctx, "concurrent", time.Minute); err == nil {
+						mu.Lock()
+						acquired++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			if acquired != 1 {
+				t.Fatalf("并发加锁成功 %d 次，应当只有 1 次", acquired)
+			}
+		})
+	}
+}
+
+func TestLockRejectsNonPositiveTTL(t *testing.T) {
+	ctx := context.Background()
+	locker, _ := NewLocker(NewMemory(nil))
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		if _, err := locker.TryLock(ctx, "job", ttl); err == nil {
+			t.Fatalf("TryLock ttl=%v 应当返回错误", ttl)
+		}
+		if _, err := locker.Lock(ctx, "job", ttl); err == nil {
+			t.Fatalf("Lock ttl=%v 应当返回错误", ttl)
+		}
+	}
+
+	// 有效期过短时看门狗返回错误而不是 panic
+	lock, err := locker.TryLock(ctx, "job", time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-lock.AutoRenew(0); err == nil {
+		t.Fatal("续期间隔为 0 时应当返回错误")
+	}
+}
+
+func TestLockAutoRenew(t *testing.T) {
+	ctx := context.Background()
+	locker, _ := NewLocker(NewMemory(nil))
+	lock, err := locker.TryLock(ctx, "job", 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := lock.AutoRenew(20 * time.Millisecond)
+	time.Sleep(300 * time.Millisecond)
+	if _, err := locker.TryLock(ctx, "job", time.Minute); !errors.Is(err, ErrLocked) {
+		t.Fatalf("看门狗续期后锁应当仍被占用，实际为 %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := <-errs; ok {
+		t.Fatalf("正常释放后看门狗不应返回错误: %v", err)
+	}
+}
+
+func TestMemoryLockSurvivesEviction(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(&MemoryConfig{MaxEntries: 2, MaxBytes: 64})
+	defer m.Close()
+
+	locker, err := NewLocker(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := locker.TryLock(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 写满缓存触发淘汰，持有中的锁不能被淘汰
+	for i := range 100 {
+		if err := m.Set(strconv.Itoa(i), i, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := locker.TryLock(ctx, "job", time.Minute); !errors.Is(err, ErrLocked) {
+		t.Fatalf("缓存写满后锁应当仍被占用，实际为 %v", err)
+	}
+	if err := lock.Extend(ctx, time.Minute); err != nil {
+		t.Fatalf("缓存写满后应当可以续期: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("缓存写满后应当可以释放: %v", err)
+	}
+}
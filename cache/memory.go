@@ -0,0 +1,395 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+type MemoryConfig struct {
+	MaxEntries      int           // 最大条目数，0 表示不限制
+	MaxBytes        int64         // 最大占用字节数（键+序列化后的值），0 表示不限制
+	CleanupInterval time.Duration // 过期数据清理间隔，默认 1 分钟
+}
+
+// Memory 进程内缓存，超出容量时按 LRU 淘汰
+type Memory struct {
+	mu     sync.Mutex
+	ll     *list.List
+	items  map[string]*list.Element
+	tags   map[string]map[string]struct{}
+	locks  map[string]memoryLock // 锁单独保存，不参与 LRU 淘汰
+	bytes  int64
+	config *MemoryConfig
+	stop   chan struct{}
+	done   chan struct{}
+	Codec  Codec // 序列化方式，默认 JSON
+	once   sync.Once
+}
+
+type memoryEntry struct {
+	key    string
+	data   []byte
+	expire time.Time
+}
+
+func (e *memoryEntry) size() int64 {
+	return int64(len(e.key) + len(e.data))
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expire.IsZero() && now.After(e.expire)
+}
+
+func NewMemory(config *MemoryConfig) *Memory {
+	if config == nil {
+		config = &MemoryConfig{}
+	}
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = time.Minute
+	}
+	m := &Memory{
+		ll:     list.New(),
+		items:  make(map[string]*list.Element),
+		tags:   make(map[string]map[string]struct{}),
+		locks:  make(map[string]memoryLock),
+		config: config,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go m.startCleanup()
+	return m
+}
+
+func (m *Memory) Get(key string, value any) error {
+	return m.GetWithContext(context.Background(), key, value)
+}
+
+func (m *Memory) GetWithContext(ctx context.Context, key string, value any) error {
+	m.mu.Lock()
+	entry, err := m.get(key)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return m.codec().Unmarshal(entry.data, value)
+}
+
+func (m *Memory) Set(key string, value any, expire time.Duration) error {
+	return m.SetWithContext(context.Background(), key, value, expire)
+}
+
+func (m *Memory) SetWithContext(ctx context.Context, key string, value any, expire time.Duration) error {
+	data, err := m.codec().Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.put(key, data, expireAt(expire))
+}
+
+func (m *Memory) Delete(key string) error {
+	return m.DeleteWithContext(context.Background(), key)
+}
+
+func (m *Memory) DeleteWithContext(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		m.removeElement(el)
+	}
+	return nil
+}
+
+func (m *Memory) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := m.get(key)
+	return err == nil, nil
+}
+
+func (m *Memory) TTL(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, err := m.get(key)
+	if err != nil {
+		return 0, err
+	}
+	if entry.expire.IsZero() {
+		return NoExpiration, nil
+	}
+	return time.Until(entry.expire), nil
+}
+
+func (m *Memory) Expire(ctx context.Context, key string, expire time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, err := m.get(key)
+	if err != nil {
+		return err
+	}
+	entry.expire = expireAt(expire)
+	return nil
+}
+
+func (m *Memory) SetNX(ctx context.Context, key string, value any, expire time.Duration) (bool, error) {
+	data, err := m.codec().Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.get(key); err == nil {
+		return false, nil
+	}
+	return true, m.put(key, data, expireAt(expire))
+}
+
+func (m *Memory) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	return m.incrExpire(ctx, key, delta, 0)
+}
+
+// incrExpire 计数器没有有效期时设置为 ttl，与自增在同一把锁内完成
+func (m *Memory) incrExpire(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		n      int64
+		expire time.Time
+	)
+	if entry, err := m.get(key); err == nil {
+		if err := m.codec().Unmarshal(entry.data, &n); err != nil {
+			return 0, errors.New("缓存数据不是整数")
+		}
+		expire = entry.expire
+	}
+	if expire.IsZero() {
+		expire = expireAt(ttl)
+	}
+
+	n += delta
+	data, err := m.codec().Marshal(n)
+	if err != nil {
+		return 0, err
+	}
+	return n, m.put(key, data, expire)
+}
+
+func (m *Memory) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return m.Incr(ctx, key, -delta)
+}
+
+func (m *Memory) GetDel(ctx context.Context, key string, value any) error {
+	m.mu.Lock()
+	entry, err := m.get(key)
+	if err == nil {
+		m.removeElement(m.items[key])
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return m.codec().Unmarshal(entry.data, value)
+}
+
+func (m *Memory) SetWithTags(ctx context.Context, key string, value any, expire time.Duration, tags ...string) error {
+	data, err := m.codec().Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.put(key, data, expireAt(expire)); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if m.tags[tag] == nil {
+			m.tags[tag] = make(map[string]struct{})
+		}
+		m.tags[tag][key] = struct{}{}
+	}
+	return nil
+}
+
+func (m *Memory) InvalidateTag(ctx context.Context, tag string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.tags[tag] {
+		if el, ok := m.items[key]; ok {
+			m.removeElement(el)
+		}
+	}
+	delete(m.tags, tag)
+	return nil
+}
+
+func (m *Memory) DeletePrefix(ctx context.Context, prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, el := range m.items {
+		if strings.HasPrefix(key, prefix) {
+			m.removeElement(el)
+		}
+	}
+	return nil
+}
+
+// Len 当前缓存条目数（包含尚未清理的过期数据）
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len()
+}
+
+// Close 停止后台过期清理，并等待清理协程退出
+func (m *Memory) Close() error {
+	m.once.Do(func() {
+		close(m.stop)
+	})
+	<-m.done
+	return nil
+}
+
+func (m *Memory) codec() Codec {
+	return codecOf(m.Codec)
+}
+
+// get 查找未过期的条目并标记为最近使用，过期数据顺带删除，调用方需持有锁
+func (m *Memory) get(key string) (*memoryEntry, error) {
+	el, ok := m.items[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	entry := el.Value.(*memoryEntry)
+	if entry.expired(time.Now()) {
+		m.removeElement(el)
+		return nil, ErrNotFound
+	}
+	m.ll.MoveToFront(el)
+	return entry, nil
+}
+
+// put 写入已序列化的数据并按容量淘汰，调用方需持有锁
+func (m *Memory) put(key string, data []byte, expire time.Time) error {
+	entry := &memoryEntry{key: key, data: data, expire: expire}
+	if m.config.MaxBytes > 0 && entry.size() > m.config.MaxBytes {
+		return errors.New("缓存数据超出内存缓存容量")
+	}
+	if el, ok := m.items[key]; ok {
+		m.removeElement(el)
+	}
+	m.items[key] = m.ll.PushFront(entry)
+	m.bytes += entry.size()
+	m.evict()
+	return nil
+}
+
+// evict 超出条目数或字节数上限时从链表尾部淘汰最久未使用的数据
+func (m *Memory) evict() {
+	for m.ll.Len() > 0 &&
+		((m.config.MaxEntries > 0 && m.ll.Len() > m.config.MaxEntries) ||
+			(m.config.MaxBytes > 0 && m.bytes > m.config.MaxBytes)) {
+		m.removeElement(m.ll.Back())
+	}
+}
+
+func (m *Memory) removeElement(el *list.Element) {
+	entry := m.ll.Remove(el).(*memoryEntry)
+	delete(m.items, entry.key)
+	m.bytes -= entry.size()
+}
+
+func (m *Memory) startCleanup() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.config.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.cleanup()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+func (m *Memory) cleanup() {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for el := m.ll.Back(); el != nil; {
+		prev := el.Prev()
+		if el.Value.(*memoryEntry).expired(now) {
+			m.removeElement(el)
+		}
+		el = prev
+	}
+
+	for key, lock := range m.locks {
+		if now.After(lock.expire) {
+			delete(m.locks, key)
+		}
+	}
+
+	// 清理标签中已经不存在的键
+	for tag, keys := range m.tags {
+		for key := range keys {
+			if _, ok := m.items[key]; !ok {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(m.tags, tag)
+		}
+	}
+}
+
+type memoryLock struct {
+	token  string
+	expire time.Time
+}
+
+func (m *Memory) acquire(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if lock, ok := m.locks[key]; ok && time.Now().Before(lock.expire) {
+		return false, nil
+	}
+	m.locks[key] = memoryLock{token: token, expire: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (m *Memory) release(ctx context.Context, key string, token string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.holds(key, token) {
+		return false, nil
+	}
+	delete(m.locks, key)
+	return true, nil
+}
+
+func (m *Memory) extend(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.holds(key, token) {
+		return false, nil
+	}
+	m.locks[key] = memoryLock{token: token, expire: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// holds 判断锁是否由 token 持有且未过期，调用方需持有锁
+func (m *Memory) holds(key string, token string) bool {
+	lock, ok := m.locks[key]
+	return ok && lock.token == token && time.Now().Before(lock.expire)
+}
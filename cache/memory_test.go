@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryLRUOrder(t *testing.T) {
+	m := NewMemory(&MemoryConfig{MaxEntries: 3})
+	defer m.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := m.Set(key, 1, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 读取 a 之后 b 成为最久未使用的条目
+	var v int
+	if err := m.Get("a", &v); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set("d", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Get("b", &v); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("b 应当被淘汰，实际为 %v", err)
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if err := m.Get(key, &v); err != nil {
+			t.Fatalf("%s 不应当被淘汰: %v", key, err)
+		}
+	}
+	if n := m.Len(); n != 3 {
+		t.Fatalf("条目数为 %d，应当为 3", n)
+	}
+}
+
+func TestMemoryMaxBytes(t *testing.T) {
+	// 每个条目为 1 字节的键加 1 字节的 JSON 数字
+	m := NewMemory(&MemoryConfig{MaxBytes: 6})
+	defer m.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := m.Set(key, 1, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := m.Len(); n != 3 {
+		t.Fatalf("容量内的条目数为 %d，应当为 3", n)
+	}
+
+	// 覆盖写入更大的值，超出预算后淘汰最久未使用的 a
+	if err := m.Set("c", 100, 0); err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	if err := m.Get("a", &v); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("a 应当被淘汰，实际为 %v", err)
+	}
+	if err := m.Get("c", &v); err != nil || v != 100 {
+		t.Fatalf("c 应当为 100，实际为 %d, %v", v, err)
+	}
+	if m.bytes > 6 {
+		t.Fatalf("占用 %d 字节，超出上限 6", m.bytes)
+	}
+
+	// 单个条目超出上限时拒绝写入，已有数据保持不变
+	if err := m.Set("big", "0123456789", 0); err == nil {
+		t.Fatal("超出容量的条目应当写入失败")
+	}
+	if err := m.Get("b", &v); err != nil {
+		t.Fatalf("写入失败不应当淘汰已有数据: %v", err)
+	}
+}
+
+func TestMemorySweeper(t *testing.T) {
+	m := NewMemory(&MemoryConfig{CleanupInterval: 10 * time.Millisecond})
+
+	if err := m.Set("keep", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetWithTags(t.Context(), "short", 1, 20*time.Millisecond, "tag"); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for m.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("过期数据未被清理，条目数为 %d", m.Len())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	m.mu.Lock()
+	_, ok := m.tags["tag"]
+	m.mu.Unlock()
+	if ok {
+		t.Fatal("过期数据的标签索引未被清理")
+	}
+
+	// 关闭后清理协程退出，不再清理过期数据，重复关闭不会出错
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set("late", 1, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := m.Len(); n != 2 {
+		t.Fatalf("关闭后过期数据不应当被清理，条目数为 %d", n)
+	}
+}
@@ -0,0 +1,429 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets 耗时直方图的桶上限，单位秒
+var latencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+var errUnsupported = errors.New("当前缓存不支持该操作")
+
+// Metrics 统计任意 Cache 的命中、未命中、写入、删除、错误次数以及各操作耗时。
+// *Metrics 本身只实现 Cache，需要 Store、Tagger 或分布式锁时使用 Wrapped 返回的包装
+type Metrics struct {
+	Name    string // 指标中的 name 标签，用于区分多个缓存
+	Cache   Cache
+	hits    atomic.Int64
+	misses  atomic.Int64
+	sets    atomic.Int64
+	deletes atomic.Int64
+	errors  atomic.Int64
+	mu      sync.Mutex
+	latency map[string]*histogram
+}
+
+type histogram struct {
+	counts []int64 // 与 latencyBuckets 一一对应，非累计
+	count  int64
+	sum    float64
+}
+
+type Stats struct {
+	Hits    int64
+	Misses  int64
+	Sets    int64
+	Deletes int64
+	Errors  int64
+	HitRate float64                 // 命中率，没有读取时为 0
+	Latency map[string]LatencyStats // 按操作名统计的耗时
+	Disk    *DiskStats              // 仅 File 后端有值
+}
+
+type LatencyStats struct {
+	Count   int64
+	Sum     time.Duration
+	Buckets []LatencyBucket // 累计计数
+}
+
+type LatencyBucket struct {
+	Le    time.Duration
+	Count int64
+}
+
+// DiskStats 文件缓存的磁盘占用，按 FileConfig.StatsInterval 定期统计
+type DiskStats struct {
+	Entries   int64
+	Bytes     int64
+	UpdatedAt time.Time
+}
+
+func NewMetrics(name string, c Cache) *Metrics {
+	return &Metrics{
+		Name:    name,
+		Cache:   c,
+		latency: make(map[string]*histogram),
+	}
+}
+
+func (m *Metrics) Get(key string, value any) error {
+	return m.get(context.Background(), key, value)
+}
+
+func (m *Metrics) Set(key string, value any, expire time.Duration) error {
+	return m.set(context.Background(), key, value, expire)
+}
+
+func (m *Metrics) Delete(key string) error {
+	return m.delete(context.Background(), key)
+}
+
+// Unwrap 返回被统计的缓存
+func (m *Metrics) Unwrap() Cache {
+	return m.Cache
+}
+
+// Wrapped 返回同样会被统计的缓存，只实现底层缓存实际支持的 Store、Tagger 与分布式锁，
+// 可以直接传给 NewLocker、NewClient、session 等按接口判断能力的地方
+func (m *Metrics) Wrapped() Cache {
+	_, store := m.Cache.(Store)
+	_, tagger := m.Cache.(Tagger)
+	_, locker := m.Cache.(lockBackend)
+	s, t, l := metricsStore{m}, metricsTagger{m}, metricsLock{m}
+	switch {
+	case store && tagger && locker:
+		return &struct {
+			*Metrics
+			metricsStore
+			metricsTagger
+			metricsLock
+		}{m, s, t, l}
+	case store && tagger:
+		return &struct {
+			*Metrics
+			metricsStore
+			metricsTagger
+		}{m, s, t}
+	case store && locker:
+		return &struct {
+			*Metrics
+			metricsStore
+			metricsLock
+		}{m, s, l}
+	case tagger && locker:
+		return &struct {
+			*Metrics
+			metricsTagger
+			metricsLock
+		}{m, t, l}
+	case store:
+		return &struct {
+			*Metrics
+			metricsStore
+		}{m, s}
+	case tagger:
+		return &struct {
+			*Metrics
+			metricsTagger
+		}{m, t}
+	case locker:
+		return &struct {
+			*Metrics
+			metricsLock
+		}{m, l}
+	default:
+		return m
+	}
+}
+
+func (m *Metrics) get(ctx context.Context, key string, value any) error {
+	return m.read("get", func() error {
+		return getContext(ctx, m.Cache, key, value)
+	})
+}
+
+func (m *Metrics) set(ctx context.Context, key string, value any, expire time.Duration) error {
+	return m.write("set", &m.sets, func() error {
+		return setContext(ctx, m.Cache, key, value, expire)
+	})
+}
+
+func (m *Metrics) delete(ctx context.Context, key string) error {
+	return m.write("delete", &m.deletes, func() error {
+		if s, ok := m.Cache.(Store); ok {
+			return s.DeleteWithContext(ctx, key)
+		}
+		return m.Cache.Delete(key)
+	})
+}
+
+// metricsStore 底层缓存实现了 Store 时提供的统计方法
+type metricsStore struct {
+	m *Metrics
+}
+
+func (w metricsStore) GetWithContext(ctx context.Context, key string, value any) error {
+	return w.m.get(ctx, key, value)
+}
+
+func (w metricsStore) SetWithContext(ctx context.Context, key string, value any, expire time.Duration) error {
+	return w.m.set(ctx, key, value, expire)
+}
+
+func (w metricsStore) DeleteWithContext(ctx context.Context, key string) error {
+	return w.m.delete(ctx, key)
+}
+
+func (w metricsStore) Exists(ctx context.Context, key string) (ok bool, err error) {
+	err = w.m.store("exists", func(s Store) (err error) {
+		ok, err = s.Exists(ctx, key)
+		return err
+	})
+	return ok, err
+}
+
+func (w metricsStore) TTL(ctx context.Context, key string) (ttl time.Duration, err error) {
+	err = w.m.store("ttl", func(s Store) (err error) {
+		ttl, err = s.TTL(ctx, key)
+		return err
+	})
+	return ttl, err
+}
+
+func (w metricsStore) Expire(ctx context.Context, key string, expire time.Duration) error {
+	return w.m.store("expire", func(s Store) error {
+		return s.Expire(ctx, key, expire)
+	})
+}
+
+func (w metricsStore) SetNX(ctx context.Context, key string, value any, expire time.Duration) (ok bool, err error) {
+	err = w.m.store("setnx", func(s Store) (err error) {
+		ok, err = s.SetNX(ctx, key, value, expire)
+		return err
+	})
+	if ok {
+		w.m.sets.Add(1)
+	}
+	return ok, err
+}
+
+func (w metricsStore) Incr(ctx context.Context, key string, delta int64) (n int64, err error) {
+	err = w.m.store("incr", func(s Store) (err error) {
+		n, err = s.Incr(ctx, key, delta)
+		return err
+	})
+	return n, err
+}
+
+func (w metricsStore) incrExpire(ctx context.Context, key string, delta int64, expire time.Duration) (n int64, err error) {
+	err = w.m.store("incr", func(s Store) (err error) {
+		n, err = incrExpire(ctx, s, key, delta, expire)
+		return err
+	})
+	return n, err
+}
+
+func (w metricsStore) Decr(ctx context.Context, key string, delta int64) (n int64, err error) {
+	err = w.m.store("decr", func(s Store) (err error) {
+		n, err = s.Decr(ctx, key, delta)
+		return err
+	})
+	return n, err
+}
+
+func (w metricsStore) GetDel(ctx context.Context, key string, value any) error {
+	return w.m.read("getdel", func() error {
+		return w.m.Cache.(Store).GetDel(ctx, key, value)
+	})
+}
+
+// metricsTagger 底层缓存实现了 Tagger 时提供的统计方法
+type metricsTagger struct {
+	m *Metrics
+}
+
+func (w metricsTagger) SetWithTags(ctx context.Context, key string, value any, expire time.Duration, tags ...string) error {
+	return w.m.write("set_with_tags", &w.m.sets, func() error {
+		return w.m.Cache.(Tagger).SetWithTags(ctx, key, value, expire, tags...)
+	})
+}
+
+func (w metricsTagger) InvalidateTag(ctx context.Context, tag string) error {
+	return w.m.write("invalidate_tag", &w.m.deletes, func() error {
+		return w.m.Cache.(Tagger).InvalidateTag(ctx, tag)
+	})
+}
+
+func (w metricsTagger) DeletePrefix(ctx context.Context, prefix string) error {
+	return w.m.write("delete_prefix", &w.m.deletes, func() error {
+		return w.m.Cache.(Tagger).DeletePrefix(ctx, prefix)
+	})
+}
+
+// metricsLock 底层缓存支持分布式锁时提供的方法，锁操作不计入统计
+type metricsLock struct {
+	m *Metrics
+}
+
+func (w metricsLock) acquire(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	return w.m.Cache.(lockBackend).acquire(ctx, key, token, ttl)
+}
+
+func (w metricsLock) release(ctx context.Context, key string, token string) (bool, error) {
+	return w.m.Cache.(lockBackend).release(ctx, key, token)
+}
+
+func (w metricsLock) extend(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	return w.m.Cache.(lockBackend).extend(ctx, key, token, ttl)
+}
+
+// read 统计读操作，ErrNotFound 记为未命中而不是错误
+func (m *Metrics) read(op string, fn func() error) error {
+	err := m.observe(op, fn)
+	switch {
+	case err == nil:
+		m.hits.Add(1)
+	case errors.Is(err, ErrNotFound):
+		m.misses.Add(1)
+	default:
+		m.errors.Add(1)
+	}
+	return err
+}
+
+func (m *Metrics) write(op string, counter *atomic.Int64, fn func() error) error {
+	err := m.observe(op, fn)
+	if err != nil {
+		m.errors.Add(1)
+	} else {
+		counter.Add(1)
+	}
+	return err
+}
+
+func (m *Metrics) store(op string, fn func(Store) error) error {
+	err := m.observe(op, func() error {
+		return fn(m.Cache.(Store))
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		m.errors.Add(1)
+	}
+	return err
+}
+
+func (m *Metrics) observe(op string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	seconds := time.Since(start).Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.latency[op]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(latencyBuckets))}
+		m.latency[op] = h
+	}
+	h.count++
+	h.sum += seconds
+	if i, _ := slices.BinarySearch(latencyBuckets, seconds); i < len(latencyBuckets) {
+		h.counts[i]++
+	}
+	return err
+}
+
+// Stats 返回当前统计数据的快照
+func (m *Metrics) Stats() Stats {
+	stats := Stats{
+		Hits:    m.hits.Load(),
+		Misses:  m.misses.Load(),
+		Sets:    m.sets.Load(),
+		Deletes: m.deletes.Load(),
+		Errors:  m.errors.Load(),
+		Latency: make(map[string]LatencyStats),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+
+	m.mu.Lock()
+	for op, h := range m.latency {
+		latency := LatencyStats{
+			Count:   h.count,
+			Sum:     time.Duration(h.sum * float64(time.Second)),
+			Buckets: make([]LatencyBucket, len(latencyBuckets)),
+		}
+		var cumulative int64
+		for i, le := range latencyBuckets {
+			cumulative += h.counts[i]
+			latency.Buckets[i] = LatencyBucket{Le: time.Duration(le * float64(time.Second)), Count: cumulative}
+		}
+		stats.Latency[op] = latency
+	}
+	m.mu.Unlock()
+
+	if f, ok := m.Cache.(*File); ok {
+		disk := f.DiskStats()
+		stats.Disk = &disk
+	}
+	return stats
+}
+
+// Handler 以 Prometheus 文本格式输出统计数据，可直接挂载到 /metrics
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus 将统计数据以 Prometheus 文本格式写入 w
+func (m *Metrics) WritePrometheus(w io.Writer) {
+	stats := m.Stats()
+	name := strconv.Quote(m.Name)
+
+	counters := []struct {
+		metric string
+		help   string
+		value  int64
+	}{
+		{"cache_hits_total", "缓存命中次数", stats.Hits},
+		{"cache_misses_total", "缓存未命中次数", stats.Misses},
+		{"cache_sets_total", "缓存写入次数", stats.Sets},
+		{"cache_deletes_total", "缓存删除次数", stats.Deletes},
+		{"cache_errors_total", "缓存操作错误次数", stats.Errors},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s{name=%s} %d\n", c.metric, c.help, c.metric, c.metric, name, c.value)
+	}
+
+	fmt.Fprint(w, "# HELP cache_operation_duration_seconds 缓存操作耗时\n# TYPE cache_operation_duration_seconds histogram\n")
+	ops := make([]string, 0, len(stats.Latency))
+	for op := range stats.Latency {
+		ops = append(ops, op)
+	}
+	slices.Sort(ops)
+	for _, op := range ops {
+		latency := stats.Latency[op]
+		labels := fmt.Sprintf("name=%s,op=%q", name, op)
+		for _, b := range latency.Buckets {
+			fmt.Fprintf(w, "cache_operation_duration_seconds_bucket{%s,le=%q} %d\n", labels, strconv.FormatFloat(b.Le.Seconds(), 'g', -1, 64), b.Count)
+		}
+		fmt.Fprintf(w, "cache_operation_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, latency.Count)
+		fmt.Fprintf(w, "cache_operation_duration_seconds_sum{%s} %g\n", labels, latency.Sum.Seconds())
+		fmt.Fprintf(w, "cache_operation_duration_seconds_count{%s} %d\n", labels, latency.Count)
+	}
+
+	if stats.Disk != nil {
+		fmt.Fprintf(w, "# HELP cache_disk_entries 文件缓存条目数\n# TYPE cache_disk_entries gauge\ncache_disk_entries{name=%s} %d\n", name, stats.Disk.Entries)
+		fmt.Fprintf(w, "# HELP cache_disk_bytes 文件缓存占用字节数\n# TYPE cache_disk_bytes gauge\ncache_disk_bytes{name=%s} %d\n", name, stats.Disk.Bytes)
+	}
+}
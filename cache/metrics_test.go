@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// plainCache 只实现 Cache 接口
+type plainCache struct {
+	data map[string]any
+}
+
+func (c *plainCache) Get(key string, value any) error {
+	v, ok := c.data[key]
+	if !ok {
+		return ErrNotFound
+	}
+	*value.(*string) = v.(string)
+	return nil
+}
+
+func (c *plainCache) Set(key string, value any, expire time.Duration) error {
+	c.data[key] = value
+	return nil
+}
+
+func (c *plainCache) Delete(key string) error {
+	delete(c.data, key)
+	return nil
+}
+
+func TestMetricsWrappedCapabilities(t *testing.T) {
+	plain := NewMetrics("plain", &plainCache{data: map[string]any{}}).Wrapped()
+	if _, ok := plain.(Store); ok {
+		t.Fatal("底层缓存不支持 Store 时包装不应实现 Store")
+	}
+	if _, ok := plain.(Tagger); ok {
+		t.Fatal("底层缓存不支持 Tagger 时包装不应实现 Tagger")
+	}
+	if _, err := NewLocker(plain); err == nil {
+		t.Fatal("底层缓存不支持分布式锁时 NewLocker 应当返回错误")
+	}
+
+	memory := NewMemory(nil)
+	defer memory.Close()
+	wrapped := NewMetrics("memory", memory).Wrapped()
+	if _, ok := wrapped.(Store); !ok {
+		t.Fatal("包装应当实现 Store")
+	}
+	if _, ok := wrapped.(Tagger); !ok {
+		t.Fatal("包装应当实现 Tagger")
+	}
+	if _, err := NewLocker(wrapped); err != nil {
+		t.Fatalf("包装应当支持分布式锁: %v", err)
+	}
+}
+
+func TestMetricsStats(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemory(nil)
+	defer memory.Close()
+	m := NewMetrics("memory", memory)
+	store := m.Wrapped().(Store)
+
+	var v string
+	if err := store.GetWithContext(ctx, "k", &v); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	store.SetWithContext(ctx, "k", "v", time.Minute)
+	store.GetWithContext(ctx, "k", &v)
+	store.GetDel(ctx, "k", &v)
+	m.Delete("k")
+
+	stats := m.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Sets != 1 || stats.Deletes != 1 || stats.Errors != 0 {
+		t.Fatalf("统计数据不正确: %+v", stats)
+	}
+	if stats.Latency["getdel"].Count != 1 {
+		t.Fatal("没有统计 GetDel 耗时")
+	}
+}
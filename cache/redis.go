@@ -2,32 +2,185 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type RedisConfig struct {
-	Addr     string
-	Password string
-	DB       int
+	Mode             string          `default:"single" validate:"in=single,sentinel,cluster:不支持的Redis部署模式"` // 部署模式：single、sentinel、cluster，默认 single
+	Addr             string          // 单节点地址
+	Addrs            []string        // 哨兵或集群节点地址，single 模式下 Addr 为空时取第一个
+	MasterName       string          // 哨兵模式的主节点名称
+	Username         string          // ACL 用户名
+	Password         string          // 密码
+	SentinelUsername string          // 哨兵 ACL 用户名
+	SentinelPassword string          // 哨兵密码
+	DB               int             // 数据库，集群模式下无效
+	TLS              *RedisTLSConfig // 为空时不使用 TLS
+	PoolSize         int             // 连接池大小，默认每个 CPU 10 个连接
+	MinIdleConns     int             // 最小空闲连接数
+	DialTimeout      time.Duration   // 连接超时，默认 5 秒
+	ReadTimeout      time.Duration   // 读超时，默认 3 秒
+	WriteTimeout     time.Duration   // 写超时，默认同 ReadTimeout
+}
+
+type RedisTLSConfig struct {
+	CertFile           string // 客户端证书，双向认证时使用
+	KeyFile            string // 客户端私钥
+	CAFile             string // 自签名服务端证书的 CA，为空使用系统根证书
+	ServerName         string // 校验证书时使用的服务端名称
+	InsecureSkipVerify bool   // 跳过证书校验，仅用于测试环境
 }
 
 type Redis struct {
-	Client *redis.Client
+	Client redis.UniversalClient
+	Codec  Codec  // 序列化方式，默认 JSON
+	Prefix string // 键前缀，多个服务共用同一个 Redis 库时用于隔离，会自动加在所有键前面
 }
 
+// NewRedis 根据部署模式创建客户端，不检查连接；部署模式、TLS 证书等配置错误时不会 panic，
+// 而是在每次操作时返回该错误，需要启动时发现错误请使用 OpenRedis
 func NewRedis(config *RedisConfig) *Redis {
-	return &Redis{
-		Client: redis.NewClient(&redis.Options{
-			Addr:     config.Addr,
-			Password: config.Password,
-			DB:       config.DB,
-		}),
+	client, err := newRedisClient(config)
+	if err != nil {
+		client = failedClient(err)
+	}
+	return &Redis{Client: client}
+}
+
+// OpenRedis 根据部署模式创建客户端并立即 Ping，配置错误在启动时即可发现
+func OpenRedis(config *RedisConfig) (*Redis, error) {
+	client, err := newRedisClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := config.DialTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	r := &Redis{Client: client}
+	if err := r.Ping(ctx); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("连接Redis失败: %w", err)
+	}
+	return r, nil
+}
+
+func newRedisClient(config *RedisConfig) (redis.UniversalClient, error) {
+	opts := &redis.UniversalOptions{
+		Addrs:            config.Addrs,
+		MasterName:       config.MasterName,
+		Username:         config.Username,
+		Password:         config.Password,
+		SentinelUsername: config.SentinelUsername,
+		SentinelPassword: config.SentinelPassword,
+		DB:               config.DB,
+		PoolSize:         config.PoolSize,
+		MinIdleConns:     config.MinIdleConns,
+		DialTimeout:      config.DialTimeout,
+		ReadTimeout:      config.ReadTimeout,
+		WriteTimeout:     config.WriteTimeout,
+	}
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.load()
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	var client redis.UniversalClient
+	switch config.Mode {
+	case "sentinel":
+		if config.MasterName == "" {
+			return nil, errors.New("哨兵模式必须配置MasterName")
+		}
+		client = redis.NewFailoverClient(opts.Failover())
+	case "cluster":
+		client = redis.NewClusterClient(opts.Cluster())
+	case "", "single":
+		if config.Addr != "" {
+			opts.Addrs = []string{config.Addr}
+		}
+		client = redis.NewClient(opts.Simple())
+	default:
+		return nil, fmt.Errorf("不支持的Redis部署模式: %s", config.Mode)
+	}
+	return client, nil
+}
+
+// failedClient 返回一个不会建立连接的客户端，所有命令都返回 err
+func failedClient(err error) redis.UniversalClient {
+	client := redis.NewClient(&redis.Options{})
+	client.AddHook(failedHook{err: err})
+	return client
+}
+
+type failedHook struct {
+	err error
+}
+
+func (h failedHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, h.err
+	}
+}
+
+func (h failedHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		cmd.SetErr(h.err)
+		return h.err
+	}
+}
+
+func (h failedHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			cmd.SetErr(h.err)
+		}
+		return h.err
 	}
 }
 
+func (c *RedisTLSConfig) load() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载Redis客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if c.CAFile != "" {
+		ca, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取Redis CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("解析Redis CA证书失败")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
 func (r *Redis) Ping(ctx context.Context) error {
 	return r.Client.Ping(ctx).Err()
 }
@@ -37,11 +190,11 @@ func (r *Redis) Get(key string, value any) error {
 }
 
 func (r *Redis) GetWithContext(ctx context.Context, key string, value any) error {
-	str, err := r.Client.Get(ctx, key).Result()
+	str, err := r.Client.Get(ctx, r.key(key)).Result()
 	if err != nil {
-		return err
+		return redisError(err)
 	}
-	return json.Unmarshal([]byte(str), value)
+	return r.codec().Unmarshal([]byte(str), value)
 }
 
 func (r *Redis) Set(key string, value any, expir time.Duration) error {
@@ -49,11 +202,11 @@ func (r *Redis) Set(key string, value any, expir time.Duration) error {
 }
 
 func (r *Redis) SetWithContext(ctx context.Context, key string, value any, expir time.Duration) error {
-	str, err := json.Marshal(value)
+	str, err := r.codec().Marshal(value)
 	if err != nil {
 		return err
 	}
-	return r.Client.Set(ctx, key, string(str), expir).Err()
+	return r.Client.Set(ctx, r.key(key), string(str), expir).Err()
 }
 
 func (r *Redis) Delete(key string) error {
@@ -61,5 +214,272 @@ func (r *Redis) Delete(key string) error {
 }
 
 func (r *Redis) DeleteWithContext(ctx context.Context, key string) error {
-	return r.Client.Del(ctx, key).Err()
+	return r.Client.Del(ctx, r.key(key)).Err()
+}
+
+func (r *Redis) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := r.Client.Exists(ctx, r.key(key)).Result()
+	return n > 0, err
+}
+
+func (r *Redis) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.Client.PTTL(ctx, r.key(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// go-redis 对不存在的键返回 -2，对永不过期的键返回 -1
+	switch ttl {
+	case -2:
+		return 0, ErrNotFound
+	case -1:
+		return NoExpiration, nil
+	}
+	return ttl, nil
+}
+
+func (r *Redis) Expire(ctx context.Context, key string, expire time.Duration) error {
+	var (
+		ok  bool
+		err error
+	)
+	if expire > 0 {
+		ok, err = r.Client.PExpire(ctx, r.key(key), expire).Result()
+	} else {
+		// PERSIST 对没有过期时间的键同样返回 false，需要再确认键是否存在
+		if ok, err = r.Client.Persist(ctx, r.key(key)).Result(); err == nil && !ok {
+			ok, err = r.Exists(ctx, key)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *Redis) SetNX(ctx context.Context, key string, value any, expire time.Duration) (bool, error) {
+	str, err := r.codec().Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return r.Client.SetNX(ctx, r.key(key), string(str), expire).Result()
+}
+
+func (r *Redis) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	return r.Client.IncrBy(ctx, r.key(key), delta).Result()
+}
+
+func (r *Redis) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return r.Client.DecrBy(ctx, r.key(key), delta).Result()
+}
+
+// incrExpireScript 自增后计数器没有有效期时设置有效期
+var incrExpireScript = redis.NewScript(`
+local n = redis.call('INCRBY', KEYS[1], ARGV[1])
+if redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return n
+`)
+
+func (r *Redis) incrExpire(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	if expire <= 0 {
+		return r.Incr(ctx, key, delta)
+	}
+	return incrExpireScript.Run(ctx, r.Client, []string{r.key(key)}, delta, max(expire.Milliseconds(), 1)).Int64()
+}
+
+// counter 读取 INCRBY 写入的纯文本整数，不经过 Codec
+func (r *Redis) counter(ctx context.Context, key string) (int64, error) {
+	n, err := r.Client.Get(ctx, r.key(key)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// GetDel 使用 GETDEL 命令，需要 Redis 6.2 及以上版本
+func (r *Redis) GetDel(ctx context.Context, key string, value any) error {
+	str, err := r.Client.GetDel(ctx, r.key(key)).Result()
+	if err != nil {
+		return redisError(err)
+	}
+	return r.codec().Unmarshal([]byte(str), value)
+}
+
+func (r *Redis) codec() Codec {
+	return codecOf(r.Codec)
+}
+
+// key 加上键前缀
+func (r *Redis) key(key string) string {
+	return r.Prefix + key
+}
+
+// redisError 将 redis.Nil 转换为 ErrNotFound
+func redisError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// addTagScript 将键加入标签集合，集合的过期时间取所有成员中最晚的一个
+var addTagScript = redis.NewScript(`
+local existed = redis.call('EXISTS', KEYS[1])
+redis.call('SADD', KEYS[1], ARGV[1])
+local ttl = tonumber(ARGV[2])
+if ttl <= 0 then
+	redis.call('PERSIST', KEYS[1])
+	return 1
+end
+local cur = redis.call('PTTL', KEYS[1])
+if existed == 0 or (cur >= 0 and cur < ttl) then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+func (r *Redis) SetWithTags(ctx context.Context, key string, value any, expire time.Duration, tags ...string) error {
+	if err := r.SetWithContext(ctx, key, value, expire); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := addTagScript.Run(ctx, r.Client, []string{r.key(tagKey(tag))}, r.key(key), expire.Milliseconds()).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Redis) InvalidateTag(ctx context.Context, tag string) error {
+	_, err := r.invalidateTag(ctx, tag)
+	return err
+}
+
+// invalidateTag 删除标签关联的数据并返回被删除的键（不含键前缀）。
+// 只从集合中移除已处理的成员而不是删除整个集合，避免丢失并发加入的键
+func (r *Redis) invalidateTag(ctx context.Context, tag string) ([]string, error) {
+	keys, err := r.Client.SMembers(ctx, r.key(tagKey(tag))).Result()
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	if err := r.unlink(ctx, keys); err != nil {
+		return nil, err
+	}
+	members := make([]any, len(keys))
+	for i, key := range keys {
+		members[i] = key
+		keys[i] = strings.TrimPrefix(key, r.Prefix)
+	}
+	return keys, r.Client.SRem(ctx, r.key(tagKey(tag)), members...).Err()
+}
+
+// DeletePrefix 使用 SCAN 分批查找并删除，不会像 KEYS 一样阻塞 Redis
+func (r *Redis) DeletePrefix(ctx context.Context, prefix string) error {
+	_, err := r.deletePrefix(ctx, prefix)
+	return err
+}
+
+// deletePrefix 返回被删除的键（含键前缀），集群模式下逐个主节点扫描
+func (r *Redis) deletePrefix(ctx context.Context, prefix string) ([]string, error) {
+	prefix = r.key(prefix)
+	cluster, ok := r.Client.(*redis.ClusterClient)
+	if !ok {
+		return r.scanDelete(ctx, r.Client, prefix)
+	}
+
+	var (
+		mu      sync.Mutex
+		deleted []string
+	)
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		keys, err := r.scanDelete(ctx, node, prefix)
+		mu.Lock()
+		deleted = append(deleted, keys...)
+		mu.Unlock()
+		return err
+	})
+	return deleted, err
+}
+
+// scanDelete 在单个节点上扫描，删除仍通过 r.Client 执行以便集群正确路由
+func (r *Redis) scanDelete(ctx context.Context, node redis.UniversalClient, prefix string) ([]string, error) {
+	var deleted []string
+	iter := node.Scan(ctx, 0, escapeGlob(prefix)+"*", 500).Iterator()
+	batch := make([]string, 0, 500)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := r.unlink(ctx, batch); err != nil {
+				return deleted, err
+			}
+			deleted = append(deleted, batch...)
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, err
+	}
+	if err := r.unlink(ctx, batch); err != nil {
+		return deleted, err
+	}
+	return append(deleted, batch...), nil
+}
+
+// unlink 逐个键通过管道删除，兼容键分布在不同槽位的集群
+func (r *Redis) unlink(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	pipe := r.Client.Pipeline()
+	for _, key := range keys {
+		pipe.Unlink(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// escapeGlob 转义 SCAN MATCH 中的通配符
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+var (
+	releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+	extendLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+func (r *Redis) acquire(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, r.key(key), token, ttl).Result()
+}
+
+func (r *Redis) release(ctx context.Context, key string, token string) (bool, error) {
+	n, err := releaseLockScript.Run(ctx, r.Client, []string{r.key(key)}, token).Int()
+	return n == 1, err
+}
+
+func (r *Redis) extend(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	n, err := extendLockScript.Run(ctx, r.Client, []string{r.key(key)}, token, ttl.Milliseconds()).Int()
+	return n == 1, err
 }
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/syncx"
+)
+
+var flights = syncx.NewSingleFlight()
+
+type rememberOptions struct {
+	negativeTTL time.Duration
+	notFound    []error
+	jitter      float64
+}
+
+type RememberOption func(*rememberOptions)
+
+// WithNegativeTTL loader 返回 ErrNotFound 或 errs 中任一错误时，缓存“数据不存在”的结果 ttl 时长，
+// 期间 Remember 直接返回 ErrNotFound 而不再调用 loader，例如 WithNegativeTTL(time.Minute, gorm.ErrRecordNotFound)
+func WithNegativeTTL(ttl time.Duration, errs ...error) RememberOption {
+	return func(o *rememberOptions) {
+		o.negativeTTL = ttl
+		o.notFound = append(o.notFound, errs...)
+	}
+}
+
+// WithJitter 在有效期上随机增加 [0, ttl*ratio) 的时长，避免大量数据同时过期
+func WithJitter(ratio float64) RememberOption {
+	return func(o *rememberOptions) {
+		o.jitter = ratio
+	}
+}
+
+// Remember 先从缓存读取，未命中时调用 loader 加载并写入缓存。
+// 同一进程内对同一个键的并发加载只会执行一次 loader，其余调用共享结果。
+func Remember[T any](ctx context.Context, c Cache, key string, ttl time.Duration, loader func() (T, error), opts ...RememberOption) (T, error) {
+	options := &rememberOptions{notFound: []error{ErrNotFound}}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var value T
+	if err := getContext(ctx, c, key, &value); err == nil {
+		return value, nil
+	}
+	if options.negativeTTL > 0 && options.cachedMiss(ctx, c, key) {
+		return value, ErrNotFound
+	}
+
+	load := func() (any, error) {
+		// 等待期间其他调用可能已经写入缓存
+		var v T
+		if err := getContext(ctx, c, key, &v); err == nil {
+			return v, nil
+		}
+		v, err := loader()
+		if err != nil {
+			if options.negativeTTL > 0 && options.isNotFound(err) {
+				setContext(ctx, c, negativeKey(key), true, options.negativeTTL)
+				return v, ErrNotFound
+			}
+			return v, err
+		}
+		// 写缓存失败不影响本次加载结果，下次调用会重新加载
+		setContext(ctx, c, key, v, options.expire(ttl))
+		return v, nil
+	}
+
+	res, err := flights.Do(fmt.Sprintf("%p:%s", c, key), load)
+	if v, ok := res.(T); ok {
+		return v, err
+	}
+	if err != nil {
+		return value, err
+	}
+	// 同一个键被以不同类型调用时共享结果无法转换，退回单独加载
+	res, err = load()
+	value, _ = res.(T)
+	return value, err
+}
+
+func (o *rememberOptions) isNotFound(err error) bool {
+	for _, target := range o.notFound {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *rememberOptions) cachedMiss(ctx context.Context, c Cache, key string) bool {
+	var miss bool
+	return getContext(ctx, c, negativeKey(key), &miss) == nil && miss
+}
+
+func (o *rememberOptions) expire(ttl time.Duration) time.Duration {
+	if ttl <= 0 || o.jitter <= 0 {
+		return ttl
+	}
+	if n := int64(float64(ttl) * o.jitter); n > 0 {
+		ttl += time.Duration(rand.Int63n(n))
+	}
+	return ttl
+}
+
+func negativeKey(key string) string {
+	return key + ":nil"
+}
+
+// getContext 后端实现了 Store 时传递 ctx，否则退回旧接口
+func getContext(ctx context.Context, c Cache, key string, value any) error {
+	if s, ok := c.(Store); ok {
+		return s.GetWithContext(ctx, key, value)
+	}
+	return c.Get(key, value)
+}
+
+func setContext(ctx context.Context, c Cache, key string, value any, expire time.Duration) error {
+	if s, ok := c.(Store); ok {
+		return s.SetWithContext(ctx, key, value, expire)
+	}
+	return c.Set(key, value, expire)
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRememberSingleflight(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(nil)
+	defer c.Close()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := Remember(ctx, c, "user", time.Minute, loader)
+			if err != nil || v != 42 {
+				t.Errorf("Remember 返回 %d, %v，应当为 42", v, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("loader 被调用 %d 次，并发加载应当只调用 1 次", n)
+	}
+	var v int
+	if err := c.Get("user", &v); err != nil || v != 42 {
+		t.Fatalf("加载结果应当写入缓存，实际为 %d, %v", v, err)
+	}
+}
+
+func TestRememberNegative(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(nil)
+	defer c.Close()
+
+	errMissing := errors.New("记录不存在")
+	var calls int
+	loader := func() (string, error) {
+		calls++
+		return "", errMissing
+	}
+
+	for range 3 {
+		_, err := Remember(ctx, c, "user", time.Minute, loader, WithNegativeTTL(30*time.Millisecond, errMissing))
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("数据不存在时应当返回 ErrNotFound，实际为 %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("loader 被调用 %d 次，不存在的结果应当被缓存", calls)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, err := Remember(ctx, c, "user", time.Minute, loader, WithNegativeTTL(30*time.Millisecond, errMissing)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("数据不存在时应当返回 ErrNotFound，实际为 %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("不存在的结果过期后应当重新加载，loader 被调用 %d 次", calls)
+	}
+
+	// 未配置 WithNegativeTTL 时原样返回错误且不缓存
+	if _, err := Remember(ctx, c, "other", time.Minute, loader); !errors.Is(err, errMissing) {
+		t.Fatalf("应当返回 loader 的错误，实际为 %v", err)
+	}
+	if _, err := Remember(ctx, c, "other", time.Minute, loader); !errors.Is(err, errMissing) {
+		t.Fatalf("应当返回 loader 的错误，实际为 %v", err)
+	}
+	if calls != 4 {
+		t.Fatalf("未开启负缓存时每次都应当调用 loader，实际调用 %d 次", calls)
+	}
+}
+
+func TestRememberTypedFallback(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(nil)
+	defer c.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan int)
+	go func() {
+		v, _ := Remember(ctx, c, "mixed", time.Minute, func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		done <- v
+	}()
+	<-started
+
+	// 以不同类型加入同一次加载，共享结果无法转换时退回单独加载
+	result := make(chan string)
+	go func() {
+		v, err := Remember(ctx, c, "mixed", time.Minute, func() (string, error) {
+			return "one", nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		result <- v
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if v := <-done; v != 1 {
+		t.Fatalf("int 调用返回 %d，应当为 1", v)
+	}
+	if v := <-result; v != "one" {
+		t.Fatalf("string 调用返回 %q，应当为 one", v)
+	}
+}
+
+func TestRememberFlightKeyPerCache(t *testing.T) {
+	ctx := context.Background()
+	first := NewMemory(nil)
+	defer first.Close()
+	second := NewMemory(nil)
+	defer second.Close()
+
+	// 不同缓存实例的同名键不能共享加载，否则第二个调用会等待第一个而死锁
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan int)
+	go func() {
+		v, _ := Remember(ctx, first, "user", time.Minute, func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		done <- v
+	}()
+	<-started
+
+	result := make(chan int)
+	go func() {
+		v, _ := Remember(ctx, second, "user", time.Minute, func() (int, error) {
+			return 2, nil
+		})
+		result <- v
+	}()
+	select {
+	case v := <-result:
+		if v != 2 {
+			t.Fatalf("第二个缓存返回 %d，应当为 2", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("不同缓存实例的加载被合并")
+	}
+
+	close(release)
+	if v := <-done; v != 1 {
+		t.Fatalf("第一个缓存返回 %d，应当为 1", v)
+	}
+}
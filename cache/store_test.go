@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testStores 返回各个后端，Redis 使用 miniredis
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	memory := NewMemory(nil)
+	file := NewFile(&FileConfig{Path: t.TempDir()})
+	t.Cleanup(func() {
+		client.Close()
+		memory.Close()
+		file.Close()
+	})
+	return map[string]Store{
+		"memory": memory,
+		"file":   file,
+		"redis":  &Redis{Client: client, Prefix: "test:"},
+	}
+}
+
+func TestStoreGetDelAtomic(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for round := range 20 {
+				if err := store.SetWithContext(ctx, "once", round, time.Minute); err != nil {
+					t.Fatal(err)
+				}
+				var wg sync.WaitGroup
+				var winners atomic.Int32
+				for range 10 {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						var v int
+						err := store.GetDel(ctx, "once", &v)
+						switch {
+						case err == nil:
+							if v != round {
+								t.Errorf("GetDel 取到 %d，应当为 %d", v, round)
+							}
+							winners.Add(1)
+						case !errors.Is(err, ErrNotFound):
+							t.Error(err)
+						}
+					}()
+				}
+				wg.Wait()
+				if n := winners.Load(); n != 1 {
+					t.Fatalf("第 %d 轮有 %d 个并发 GetDel 取到数据，应当只有 1 个", round, n)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreSetNXAndIncr(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			var written atomic.Int32
+			for range 20 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if ok, err := store.SetNX(ctx, "nx", "v", time.Minute); err != nil {
+						t.Error(err)
+					} else if ok {
+						written.Add(1)
+					}
+					if _, err := store.Incr(ctx, "counter", 2); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+			if n := written.Load(); n != 1 {
+				t.Fatalf("并发 SetNX 成功 %d 次，应当只有 1 次", n)
+			}
+			if n, err := store.Decr(ctx, "counter", 1); err != nil || n != 39 {
+				t.Fatalf("Decr = %d, %v，应当为 39", n, err)
+			}
+		})
+	}
+}
+
+func TestStoreTTL(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.TTL(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("不存在的键应当返回 ErrNotFound，实际为 %v", err)
+			}
+			store.SetWithContext(ctx, "k", "v", 0)
+			if ttl, err := store.TTL(ctx, "k"); err != nil || ttl != NoExpiration {
+				t.Fatalf("永不过期的键 TTL = %v, %v", ttl, err)
+			}
+			if err := store.Expire(ctx, "k", time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if ttl, err := store.TTL(ctx, "k"); err != nil || ttl <= 0 || ttl > time.Minute {
+				t.Fatalf("TTL = %v, %v", ttl, err)
+			}
+			if ok, err := store.Exists(ctx, "k"); err != nil || !ok {
+				t.Fatalf("Exists = %v, %v", ok, err)
+			}
+			store.DeleteWithContext(ctx, "k")
+			if ok, _ := store.Exists(ctx, "k"); ok {
+				t.Fatal("删除后仍然存在")
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Tagger 支持按标签、按前缀批量删除的缓存，
+// 例如分页列表以 product 为标签写入，商品修改后 InvalidateTag("product") 删除所有列表页
+type Tagger interface {
+	SetWithTags(ctx context.Context, key string, value any, expire time.Duration, tags ...string) error // 写入数据并关联标签
+	InvalidateTag(ctx context.Context, tag string) error                                                // 删除标签关联的所有数据
+	DeletePrefix(ctx context.Context, prefix string) error                                              // 删除键以 prefix 开头的所有数据
+}
+
+// tagPrefix 标签索引的键前缀
+const tagPrefix = "cache_tag:"
+
+func tagKey(tag string) string {
+	return tagPrefix + tag
+}
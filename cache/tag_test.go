@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTagInvalidate(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			tagger := store.(Tagger)
+			writes := map[string][]string{
+				"product:1": {"product"},
+				"product:2": {"product", "list"},
+				"order:1":   {"list"},
+			}
+			for key, tags := range writes {
+				if err := tagger.SetWithTags(ctx, key, key, time.Minute, tags...); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := tagger.InvalidateTag(ctx, "product"); err != nil {
+				t.Fatal(err)
+			}
+			assertExists(t, store, "product:1", false)
+			assertExists(t, store, "product:2", false)
+			assertExists(t, store, "order:1", true)
+			assertExists(t, store, tagKey("product"), false)
+
+			// 标签失效后重新关联的数据可以再次失效
+			if err := tagger.SetWithTags(ctx, "product:1", "product:1", time.Minute, "product"); err != nil {
+				t.Fatal(err)
+			}
+			if err := tagger.InvalidateTag(ctx, "product"); err != nil {
+				t.Fatal(err)
+			}
+			assertExists(t, store, "product:1", false)
+
+			if err := tagger.InvalidateTag(ctx, "list"); err != nil {
+				t.Fatal(err)
+			}
+			assertExists(t, store, "order:1", false)
+			if err := tagger.InvalidateTag(ctx, "missing"); err != nil {
+				t.Fatalf("不存在的标签应当直接返回，实际为 %v", err)
+			}
+		})
+	}
+}
+
+func TestTagDeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"user:1", "user:2", "order:1"} {
+				if err := store.SetWithContext(ctx, key, 1, time.Minute); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := store.(Tagger).DeletePrefix(ctx, "user:"); err != nil {
+				t.Fatal(err)
+			}
+			assertExists(t, store, "user:1", false)
+			assertExists(t, store, "user:2", false)
+			assertExists(t, store, "order:1", true)
+		})
+	}
+}
+
+func assertExists(t *testing.T, store Store, key string, want bool) {
+	t.Helper()
+	got, err := store.Exists(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("%s 存在: %v，应当为 %v", key, got, want)
+	}
+}
+
+func TestFileRemoveTagKeepsNewKeys(t *testing.T) {
+	ctx := context.Background()
+	f := NewFile(&FileConfig{Path: t.TempDir()})
+	defer f.Close()
+
+	if err := f.SetWithTags(ctx, "a", 1, time.Minute, "tag"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.SetWithTags(ctx, "b", 1, time.Minute, "tag"); err != nil {
+		t.Fatal(err)
+	}
+	// 模拟失效过程中 b 被并发加入索引：只移除已经删除的 a
+	if err := f.removeTag(tagKey("tag"), []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	if err := f.Get(tagKey("tag"), &keys); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "b" {
+		t.Fatalf("索引为 %v，应当只剩 b", keys)
+	}
+}
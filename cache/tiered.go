@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type TieredConfig struct {
+	LocalTTL time.Duration // 本地缓存有效期，默认 30 秒，不会超过 Redis 中的剩余有效期
+	Channel  string        // 失效通知使用的 Redis 频道，默认 Redis 键前缀加 cache:invalidate
+	Memory   *MemoryConfig // 本地缓存配置
+}
+
+// Tiered 二级缓存：本地内存缓存在前，Redis 在后。
+// 任意节点写入或删除数据时通过 Redis 发布订阅通知所有节点删除本地副本，
+// 订阅断线期间错过的通知由 LocalTTL 兜底。
+type Tiered struct {
+	Local      *Memory
+	Remote     *Redis
+	config     *TieredConfig
+	node       string
+	pubsub     *redis.PubSub
+	generation atomic.Uint64 // 每次失效加一，读取 Redis 期间发生过失效时不回填本地缓存
+}
+
+func NewTiered(remote *Redis, config *TieredConfig) *Tiered {
+	if config == nil {
+		config = &TieredConfig{}
+	}
+	if config.LocalTTL <= 0 {
+		config.LocalTTL = 30 * time.Second
+	}
+	if config.Channel == "" {
+		config.Channel = remote.Prefix + "cache:invalidate"
+	}
+	t := &Tiered{
+		Local:  NewMemory(config.Memory),
+		Remote: remote,
+		config: config,
+		node:   uuid.New().String(),
+		pubsub: remote.Client.Subscribe(context.Background(), config.Channel),
+	}
+	// 本地缓存保存的是 Redis 中的原始数据，序列化方式必须一致
+	t.Local.Codec = remote.Codec
+	go t.listen()
+	return t
+}
+
+func (t *Tiered) Get(key string, value any) error {
+	return t.GetWithContext(context.Background(), key, value)
+}
+
+func (t *Tiered) GetWithContext(ctx context.Context, key string, value any) error {
+	t.Local.mu.Lock()
+	entry, err := t.Local.get(key)
+	t.Local.mu.Unlock()
+	if err == nil {
+		return t.Remote.codec().Unmarshal(entry.data, value)
+	}
+
+	generation := t.generation.Load()
+	pipe := t.Remote.Client.Pipeline()
+	get := pipe.Get(ctx, t.Remote.key(key))
+	ttl := pipe.PTTL(ctx, t.Remote.key(key))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return redisError(err)
+	}
+	data, err := get.Bytes()
+	if err != nil {
+		return redisError(err)
+	}
+
+	expire := t.config.LocalTTL
+	if remain := ttl.Val(); remain > 0 && remain < expire {
+		expire = remain
+	}
+	t.fill(key, data, expire, generation)
+	return t.Remote.codec().Unmarshal(data, value)
+}
+
+// fill 回填本地缓存，generation 为读取 Redis 之前的失效计数，
+// 期间收到过失效通知时读到的可能是旧数据，不回填
+func (t *Tiered) fill(key string, data []byte, expire time.Duration, generation uint64) {
+	t.Local.mu.Lock()
+	defer t.Local.mu.Unlock()
+	if t.generation.Load() != generation {
+		return
+	}
+	t.Local.put(key, data, expireAt(expire))
+}
+
+func (t *Tiered) Set(key string, value any, expire time.Duration) error {
+	return t.SetWithContext(context.Background(), key, value, expire)
+}
+
+func (t *Tiered) SetWithContext(ctx context.Context, key string, value any, expire time.Duration) error {
+	if err := t.Remote.SetWithContext(ctx, key, value, expire); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+func (t *Tiered) Delete(key string) error {
+	return t.DeleteWithContext(context.Background(), key)
+}
+
+func (t *Tiered) DeleteWithContext(ctx context.Context, key string) error {
+	if err := t.Remote.DeleteWithContext(ctx, key); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+func (t *Tiered) Exists(ctx context.Context, key string) (bool, error) {
+	return t.Remote.Exists(ctx, key)
+}
+
+func (t *Tiered) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return t.Remote.TTL(ctx, key)
+}
+
+func (t *Tiered) Expire(ctx context.Context, key string, expire time.Duration) error {
+	if err := t.Remote.Expire(ctx, key, expire); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+func (t *Tiered) SetNX(ctx context.Context, key string, value any, expire time.Duration) (bool, error) {
+	ok, err := t.Remote.SetNX(ctx, key, value, expire)
+	if err != nil || !ok {
+		return ok, err
+	}
+	return true, t.invalidate(ctx, key)
+}
+
+func (t *Tiered) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	n, err := t.Remote.Incr(ctx, key, delta)
+	if err != nil {
+		return 0, err
+	}
+	return n, t.invalidate(ctx, key)
+}
+
+func (t *Tiered) incrExpire(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	n, err := t.Remote.incrExpire(ctx, key, delta, expire)
+	if err != nil {
+		return 0, err
+	}
+	return n, t.invalidate(ctx, key)
+}
+
+func (t *Tiered) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return t.Incr(ctx, key, -delta)
+}
+
+// counter 计数器不进入本地缓存，直接读取 Redis
+func (t *Tiered) counter(ctx context.Context, key string) (int64, error) {
+	return t.Remote.counter(ctx, key)
+}
+
+func (t *Tiered) GetDel(ctx context.Context, key string, value any) error {
+	if err := t.Remote.GetDel(ctx, key, value); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+// Close 取消订阅并停止本地缓存的后台清理，不会关闭 Redis 连接
+func (t *Tiered) Close() error {
+	t.Local.Close()
+	return t.pubsub.Close()
+}
+
+func (t *Tiered) SetWithTags(ctx context.Context, key string, value any, expire time.Duration, tags ...string) error {
+	if err := t.Remote.SetWithTags(ctx, key, value, expire, tags...); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+func (t *Tiered) InvalidateTag(ctx context.Context, tag string) error {
+	keys, err := t.Remote.invalidateTag(ctx, tag)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := t.invalidate(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Tiered) DeletePrefix(ctx context.Context, prefix string) error {
+	if err := t.Remote.DeletePrefix(ctx, prefix); err != nil {
+		return err
+	}
+	t.generation.Add(1)
+	t.Local.DeletePrefix(ctx, prefix)
+	return t.publish(ctx, "p", prefix)
+}
+
+// invalidate 删除本地副本并通知其他节点
+func (t *Tiered) invalidate(ctx context.Context, key string) error {
+	t.generation.Add(1)
+	t.Local.DeleteWithContext(ctx, key)
+	return t.publish(ctx, "k", key)
+}
+
+// publish 发布失效通知，消息格式为 "节点ID|类型|键"，类型 k 表示单个键，p 表示前缀
+func (t *Tiered) publish(ctx context.Context, kind string, key string) error {
+	return t.Remote.Client.Publish(ctx, t.config.Channel, t.node+"|"+kind+"|"+key).Err()
+}
+
+// listen 处理其他节点发出的失效通知
+func (t *Tiered) listen() {
+	for msg := range t.pubsub.Channel() {
+		node, rest, _ := strings.Cut(msg.Payload, "|")
+		kind, key, ok := strings.Cut(rest, "|")
+		if !ok || node == t.node {
+			continue
+		}
+		t.generation.Add(1)
+		switch kind {
+		case "k":
+			t.Local.Delete(key)
+		case "p":
+			t.Local.DeletePrefix(context.Background(), key)
+		}
+	}
+}
+
+func (t *Tiered) acquire(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	return t.Remote.acquire(ctx, key, token, ttl)
+}
+
+func (t *Tiered) release(ctx context.Context, key string, token string) (bool, error) {
+	return t.Remote.release(ctx, key, token)
+}
+
+func (t *Tiered) extend(ctx context.Context, key string, token string, ttl time.Duration) (bool, error) {
+	return t.Remote.extend(ctx, key, token, ttl)
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestTiered 创建连接到 mr 的二级缓存，nodes 为创建后订阅频道的节点总数
+func newTestTiered(t *testing.T, mr *miniredis.Miniredis, codec Codec, nodes int) *Tiered {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	tiered := NewTiered(&Redis{Client: client, Codec: codec}, nil)
+	// 等待订阅生效，避免丢失测试中的第一条通知
+	deadline := time.Now().Add(2 * time.Second)
+	for mr.PubSubNumSub(tiered.config.Channel)[tiered.config.Channel] < nodes {
+		if time.Now().After(deadline) {
+			t.Fatal("订阅失效通知超时")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Cleanup(func() {
+		tiered.Close()
+		client.Close()
+	})
+	return tiered
+}
+
+func TestTieredInvalidatesOtherNodes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	a := newTestTiered(t, mr, nil, 1)
+	b := newTestTiered(t, mr, nil, 2)
+
+	if err := a.Set("k", "v1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	if err := b.Get("k", &got); err != nil || got != "v1" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+	if err := a.Set("k", "v2", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if err := b.Get("k", &got); err != nil {
+			t.Fatal(err)
+		}
+		if got == "v2" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("节点 b 仍然返回旧数据 %q", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTieredLocalHitUsesRemoteCodec(t *testing.T) {
+	mr := miniredis.RunT(t)
+	tiered := newTestTiered(t, mr, NewCodec("msgpack", "", 0), 1)
+
+	if err := tiered.Set("k", map[string]int{"n": 1}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]int
+	if err := tiered.Get("k", &got); err != nil {
+		t.Fatal(err)
+	}
+	// 直接删除 Redis 中的数据，本地副本仍然可以正常解码
+	mr.Del("k")
+	got = nil
+	if err := tiered.Get("k", &got); err != nil || got["n"] != 1 {
+		t.Fatalf("本地缓存未命中: %v, %v", got, err)
+	}
+}
+
+func TestTieredFillSkipsAfterInvalidation(t *testing.T) {
+	mr := miniredis.RunT(t)
+	tiered := newTestTiered(t, mr, nil, 1)
+
+	generation := tiered.generation.Load()
+	// 模拟读取 Redis 期间收到失效通知
+	if err := tiered.invalidate(context.Background(), "k"); err != nil {
+		t.Fatal(err)
+	}
+	tiered.fill("k", []byte(`"stale"`), time.Minute, generation)
+	if ok, _ := tiered.Local.Exists(context.Background(), "k"); ok {
+		t.Fatal("失效后仍然回填了旧数据")
+	}
+
+	tiered.fill("k", []byte(`"fresh"`), time.Minute, tiered.generation.Load())
+	if ok, _ := tiered.Local.Exists(context.Background(), "k"); !ok {
+		t.Fatal("没有失效时应当回填本地缓存")
+	}
+}
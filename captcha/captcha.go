@@ -7,35 +7,59 @@ import (
 	"github.com/ligaolin/goweb/v2/cache"
 )
 
+// Captcha 通用验证码，生成时可以绑定任意数据 T（例如用户 ID、操作类型），验证成功后原样返回，
+// T 需要能被缓存的 Codec 序列化
 type Captcha[T any] struct {
-	Client *cache.Client
+	Client  *cache.Client
+	options *options
 }
 
-func NewCaptcha[T any](c *cache.Client) *Captcha[T] {
-	return &Captcha[T]{Client: c}
+// entry 验证码与绑定数据一起保存，字段与 value 一致，校验时可以直接按 value 读取
+type entry[T any] struct {
+	Code     string
+	Carrier  string
+	Purpose  string
+	ExpireAt time.Time
+	Payload  T
 }
 
-func (c *Captcha[T]) Generate(key string, expir time.Duration) (string, error) {
-	code := generateCode()
-	v := value{
-		Code: code,
+func NewCaptcha[T any](c *cache.Client, opts ...Option) *Captcha[T] {
+	return &Captcha[T]{
+		Client:  c,
+		options: newOptions(&RandomCode{}, opts),
+	}
+}
+
+// Generate 生成验证码并绑定 payload，返回 uuid 和验证码文本，验证码由调用方自行下发。
+// 通过 WithPurpose 指定用途后，只有相同用途的 Verify 才能通过
+func (c *Captcha[T]) Generate(key string, payload T, expir time.Duration, opts ...CallOption) (string, string, error) {
+	code, err := c.options.generator.Generate()
+	if err != nil {
+		return "", "", err
+	}
+	v := entry[T]{
+		Code:     code,
+		Purpose:  newCall(opts).purpose,
+		ExpireAt: expireAt(expir),
+		Payload:  payload,
 	}
 	uuid, err := c.Client.Set(key, v, expir)
 	if err != nil {
-		return "", fmt.Errorf("存储验证码失败: %w", err)
+		return "", "", fmt.Errorf("存储验证码失败: %w", err)
 	}
-	return uuid, nil
+	return uuid, code, nil
 }
 
-func (c *Captcha[T]) Verify(key string, uuid string, code string) error {
-	var val value
-	if err := c.Client.GetAndDelete(uuid, key, &val); err != nil {
-		return fmt.Errorf("验证码不存在或已过期: %w", err)
-	}
-	if val.Code != code {
-		return fmt.Errorf("验证码错误")
+// Verify 校验验证码，成功时返回生成时绑定的数据，
+// 错误时返回 ErrExpired、ErrMismatch、ErrPurposeMismatch 或 ErrTooManyAttempts
+func (c *Captcha[T]) Verify(key string, uuid string, code string, opts ...CallOption) (T, error) {
+	var e entry[T]
+	v := &verifier{client: c.Client, key: key, options: c.options, dst: &e}
+	if err := v.verify(uuid, "", code, false, newCall(opts)); err != nil {
+		var zero T
+		return zero, err
 	}
-	return nil
+	return e.Payload, nil
 }
 
 func (c *Captcha[T]) Delete(key string, uuid string) error {
@@ -2,7 +2,7 @@ package captcha
 
 import (
 	"fmt"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/ligaolin/goweb/v2/cache"
@@ -10,18 +10,22 @@ import (
 )
 
 type ImageConfig struct {
-	Width      int `json:"width"`
-	Height     int `json:"height"`
-	Length     int `json:"length"`
-	NoiseCount int `json:"noise_count"`
+	Type       string `json:"type"` // 类型：string 字母数字、math 算术、chinese 汉字、audio 语音，默认 string
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Length     int    `json:"length"`
+	NoiseCount int    `json:"noise_count"`
+	Language   string `json:"language"` // 语音验证码的语言：zh、en、ja、ru，默认 zh
 }
 
 type Image struct {
-	Client *cache.Client
-	Config *ImageConfig
+	Client  *cache.Client
+	Config  *ImageConfig
+	options *options
+	driver  base64Captcha.Driver // 创建时生成，汉字验证码需要加载字体，不能每次生成时重建
 }
 
-func NewImage(c *cache.Client, config *ImageConfig) *Image {
+func NewImage(c *cache.Client, config *ImageConfig, opts ...Option) *Image {
 	if config == nil {
 		config = &ImageConfig{
 			Width:      240,
@@ -42,34 +46,39 @@ func NewImage(c *cache.Client, config *ImageConfig) *Image {
 	if config.NoiseCount < 0 {
 		config.NoiseCount = 3
 	}
+	if config.Language == "" {
+		config.Language = "zh"
+	}
+
+	generator := &RandomCode{Length: config.Length, Alphabet: Alnum}
+	switch config.Type {
+	case "chinese":
+		generator.Alphabet = base64Captcha.TxtChineseCharaters
+	case "audio":
+		generator.Alphabet = Digits
+	}
 	return &Image{
-		Client: c,
-		Config: config,
+		Client:  c,
+		Config:  config,
+		options: newOptions(generator, opts),
+		driver:  newDriver(config),
 	}
 }
 
+// Generate 生成验证码，返回的 base64 为 data URI，语音验证码为 WAV 音频，其他类型为 PNG 图片
 func (i *Image) Generate(expir time.Duration) (string, string, error) {
-	driver := base64Captcha.NewDriverString(
-		i.Config.Height,
-		i.Config.Width,
-		i.Config.NoiseCount,
-		base64Captcha.OptionShowHollowLine,
-		i.Config.Length,
-		base64Captcha.TxtNumbers+base64Captcha.TxtAlphabet,
-		nil,
-		nil,
-		[]string{},
-	)
-
-	_, content, answer := driver.GenerateIdQuestionAnswer()
-	item, err := driver.DrawCaptcha(content)
+	question, answer, err := i.challenge()
+	if err != nil {
+		return "", "", err
+	}
+	item, err := i.driver.DrawCaptcha(question)
 	if err != nil {
 		return "", "", fmt.Errorf("生成图片验证码失败: %w", err)
 	}
 	b64s := item.EncodeB64string()
-	uuid, err := i.Client.Set("captcha-image", value{
-		Code:    answer,
-		Carrier: "",
+	uuid, err := i.Client.Set(i.key(), value{
+		Code:     answer,
+		ExpireAt: expireAt(expir),
 	}, expir)
 	if err != nil {
 		return "", "", fmt.Errorf("存储图片验证码失败: %w", err)
@@ -77,17 +86,66 @@ func (i *Image) Generate(expir time.Duration) (string, string, error) {
 	return uuid, b64s, nil
 }
 
-func (i *Image) Verify(uuid string, code string) error {
-	var val value
-	if err := i.Client.GetAndDelete(uuid, "captcha-image", &val); err != nil {
-		return fmt.Errorf("验证码不存在或已过期: %w", err)
+// Verify 校验验证码，错误时返回 ErrExpired、ErrMismatch 或 ErrTooManyAttempts
+func (i *Image) Verify(uuid string, code string, opts ...CallOption) error {
+	v := &verifier{client: i.Client, key: i.key(), fold: true, options: i.options}
+	return v.verify(uuid, "", code, false, newCall(opts))
+}
+
+func (i *Image) Delete(uuid string) error {
+	return i.Client.Delete(uuid, i.key())
+}
+
+// challenge 返回展示给用户的内容和答案，算术验证码的答案为计算结果
+func (i *Image) challenge() (question string, answer string, err error) {
+	if i.Config.Type == "math" {
+		return mathChallenge()
 	}
-	if !strings.EqualFold(val.Code, code) {
-		return fmt.Errorf("验证码错误")
+	code, err := i.options.generator.Generate()
+	return code, code, err
+}
+
+func newDriver(c *ImageConfig) base64Captcha.Driver {
+	switch c.Type {
+	case "math":
+		return base64Captcha.NewDriverMath(c.Height, c.Width, c.NoiseCount, base64Captcha.OptionShowHollowLine, nil, nil, nil)
+	case "chinese":
+		return base64Captcha.NewDriverChinese(c.Height, c.Width, c.NoiseCount, base64Captcha.OptionShowHollowLine, c.Length, base64Captcha.TxtChineseCharaters, nil, nil, []string{"wqy-microhei.ttc"})
+	case "audio":
+		return base64Captcha.NewDriverAudio(c.Length, c.Language)
 	}
-	return nil
+	return base64Captcha.NewDriverString(c.Height, c.Width, c.NoiseCount, base64Captcha.OptionShowHollowLine, c.Length, base64Captcha.TxtNumbers+base64Captcha.TxtAlphabet, nil, nil, []string{})
 }
 
-func (i *Image) Delete(uuid string) error {
-	return i.Client.Delete(uuid, "captcha-image")
+// key 各类型的验证码分开存储，避免用一种验证码的答案通过另一种验证
+func (i *Image) key() string {
+	switch i.Config.Type {
+	case "math", "chinese", "audio":
+		return "captcha-image-" + i.Config.Type
+	}
+	return "captcha-image"
+}
+
+// mathChallenge 生成 20 以内的加减法或 10 以内的乘法题目
+func mathChallenge() (string, string, error) {
+	var n [3]int
+	for j, size := range []int{3, 20, 20} {
+		v, err := randInt(size)
+		if err != nil {
+			return "", "", err
+		}
+		n[j] = v
+	}
+	a, b := n[1], n[2]
+	switch n[0] {
+	case 0:
+		return fmt.Sprintf("%d+%d=?", a, b), strconv.Itoa(a + b), nil
+	case 1:
+		a, b = a%10, b%10
+		return fmt.Sprintf("%dx%d=?", a, b), strconv.Itoa(a * b), nil
+	}
+	if a < b {
+		a, b = b, a
+	}
+	return fmt.Sprintf("%d-%d=?", a, b), strconv.Itoa(a - b), nil
 }
@@ -0,0 +1,36 @@
+package captcha
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImageTypes(t *testing.T) {
+	client := newTestClient(t)
+	for _, typ := range []string{"", "math", "chinese", "audio"} {
+		t.Run(typ, func(t *testing.T) {
+			img := NewImage(client, &ImageConfig{Type: typ})
+			driver := img.driver
+			for range 2 {
+				uuid, b64s, err := img.Generate(time.Minute)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.HasPrefix(b64s, "data:") {
+					t.Fatalf("返回的不是 data URI: %.20s", b64s)
+				}
+				var v value
+				if err := client.Get(uuid, img.key(), &v); err != nil {
+					t.Fatal(err)
+				}
+				if err := img.Verify(uuid, strings.ToLower(v.Code)); err != nil {
+					t.Fatalf("验证失败: %v", err)
+				}
+			}
+			if img.driver != driver {
+				t.Fatal("生成验证码时不应重建 driver")
+			}
+		})
+	}
+}
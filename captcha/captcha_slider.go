@@ -0,0 +1,274 @@
+package captcha
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/cache"
+)
+
+type SliderConfig struct {
+	Width       int      `json:"width"`       // 背景图宽度，默认 300
+	Height      int      `json:"height"`      // 背景图高度，默认 150
+	PieceSize   int      `json:"piece_size"`  // 拼图块边长，默认 50
+	Tolerance   int      `json:"tolerance"`   // 允许的横向误差，像素，默认 5
+	Backgrounds []string `json:"backgrounds"` // 背景图片路径（PNG 或 JPEG），缩放裁剪为 Width x Height，为空时随机生成
+}
+
+// Slider 滑动拼图验证码：用户把拼图块拖到背景图的缺口处，提交拼图块左边缘的横坐标
+type Slider struct {
+	Client      *cache.Client
+	Config      *SliderConfig
+	options     *options
+	backgrounds []image.Image
+}
+
+// SliderPuzzle 返回给前端的拼图，图片均为 PNG data URI
+type SliderPuzzle struct {
+	Background string `json:"background"` // 带缺口的背景图
+	Piece      string `json:"piece"`      // 拼图块，透明背景
+	Y          int    `json:"y"`          // 拼图块在背景图中的纵坐标
+	Width      int    `json:"width"`      // 背景图宽度
+	Height     int    `json:"height"`     // 背景图高度
+}
+
+// NewSlider 创建滑动验证码。位置允许误差，多次尝试容易被逐步逼近，
+// 默认每个验证码只能验证一次，失败后需要重新生成，可以通过 WithMaxAttempts 修改
+func NewSlider(c *cache.Client, config *SliderConfig, opts ...Option) (*Slider, error) {
+	if config == nil {
+		config = &SliderConfig{}
+	}
+	if config.Width <= 0 {
+		config.Width = 300
+	}
+	if config.Height <= 0 {
+		config.Height = 150
+	}
+	if config.PieceSize <= 0 {
+		config.PieceSize = 50
+	}
+	if config.Tolerance <= 0 {
+		config.Tolerance = 5
+	}
+	if r := config.PieceSize / 5; config.Width < 3*(config.PieceSize+r) || config.Height < config.PieceSize+r {
+		return nil, fmt.Errorf("滑动验证码尺寸过小")
+	}
+
+	s := &Slider{
+		Client:  c,
+		Config:  config,
+		options: newOptions(nil, append([]Option{WithMaxAttempts(1)}, opts...)),
+	}
+	for _, path := range config.Backgrounds {
+		img, err := loadImage(path)
+		if err != nil {
+			return nil, fmt.Errorf("加载滑动验证码背景图 %s 失败: %w", path, err)
+		}
+		s.backgrounds = append(s.backgrounds, cover(img, config.Width, config.Height))
+	}
+	return s, nil
+}
+
+func (s *Slider) Generate(expir time.Duration) (string, *SliderPuzzle, error) {
+	bg, err := s.background()
+	if err != nil {
+		return "", nil, err
+	}
+
+	size := s.Config.PieceSize
+	r := size / 5
+	x, err := randInt(s.Config.Width - 2*(size+r))
+	if err != nil {
+		return "", nil, err
+	}
+	x += size + r
+	y, err := randInt(s.Config.Height - size - r + 1)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// 拼图块由正方形加上方、右侧两个半圆凸起组成，(x, y) 为外接矩形左上角
+	mask := pieceMask(size, r)
+	piece := image.NewNRGBA(image.Rect(0, 0, size+r, size+r))
+	for py := range size + r {
+		for px := range size + r {
+			if !mask(px, py) {
+				continue
+			}
+			c := color.NRGBAModel.Convert(bg.At(x+px, y+py)).(color.NRGBA)
+			if !mask(px-1, py) || !mask(px+1, py) || !mask(px, py-1) || !mask(px, py+1) {
+				c = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			piece.SetNRGBA(px, py, c)
+			// 背景图上的缺口压暗显示
+			d := color.NRGBAModel.Convert(bg.At(x+px, y+py)).(color.NRGBA)
+			bg.SetNRGBA(x+px, y+py, color.NRGBA{R: d.R / 3, G: d.G / 3, B: d.B / 3, A: 255})
+		}
+	}
+
+	puzzle := &SliderPuzzle{Y: y, Width: s.Config.Width, Height: s.Config.Height}
+	if puzzle.Background, err = encodePNG(bg); err != nil {
+		return "", nil, err
+	}
+	if puzzle.Piece, err = encodePNG(piece); err != nil {
+		return "", nil, err
+	}
+	uuid, err := s.Client.Set("captcha-slider", value{
+		Code:     strconv.Itoa(x),
+		ExpireAt: expireAt(expir),
+	}, expir)
+	if err != nil {
+		return "", nil, fmt.Errorf("存储滑动验证码失败: %w", err)
+	}
+	return uuid, puzzle, nil
+}
+
+// Verify 校验拼图块的横坐标，与正确位置相差不超过 Tolerance 像素即通过，
+// 错误时返回 ErrExpired、ErrMismatch 或 ErrTooManyAttempts
+func (s *Slider) Verify(uuid string, code string, opts ...CallOption) error {
+	v := &verifier{client: s.Client, key: "captcha-slider", options: s.options, match: s.match}
+	return v.verify(uuid, "", code, false, newCall(opts))
+}
+
+func (s *Slider) Delete(uuid string) error {
+	return s.Client.Delete(uuid, "captcha-slider")
+}
+
+func (s *Slider) match(expected string, actual string) bool {
+	want, err := strconv.Atoi(expected)
+	if err != nil {
+		return false
+	}
+	got, err := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+	if err != nil {
+		return false
+	}
+	return math.Abs(got-float64(want)) <= float64(s.Config.Tolerance)
+}
+
+// background 随机选择一张背景图，没有配置背景图时生成渐变色块背景
+func (s *Slider) background() (*image.NRGBA, error) {
+	bounds := image.Rect(0, 0, s.Config.Width, s.Config.Height)
+	bg := image.NewNRGBA(bounds)
+	if len(s.backgrounds) > 0 {
+		i, err := randInt(len(s.backgrounds))
+		if err != nil {
+			return nil, err
+		}
+		draw.Draw(bg, bounds, s.backgrounds[i], image.Point{}, draw.Src)
+		return bg, nil
+	}
+
+	var c [7]int
+	for i := range c {
+		v, err := randInt(256)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = v
+	}
+	w, h := s.Config.Width, s.Config.Height
+	for y := range h {
+		for x := range w {
+			t := float64(x) / float64(w)
+			bg.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(float64(c[0])*(1-t) + float64(c[3])*t),
+				G: uint8(float64(c[1])*(1-t) + float64(c[4])*t),
+				B: uint8(float64(c[2])*(1-t) + float64(c[5])*t),
+				A: 255,
+			})
+		}
+	}
+	// 叠加随机圆形色块，避免缺口位置可以通过纯色背景直接识别
+	for range 6 + c[6]%6 {
+		var v [6]int
+		for i, n := range []int{w, h, h/2 + 1, 256, 256, 256} {
+			r, err := randInt(n)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = r
+		}
+		cx, cy, radius := v[0], v[1], v[2]+h/8
+		fill := color.NRGBA{R: uint8(v[3]), G: uint8(v[4]), B: uint8(v[5]), A: 255}
+		for y := max(cy-radius, 0); y < min(cy+radius, h); y++ {
+			for x := max(cx-radius, 0); x < min(cx+radius, w); x++ {
+				if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= radius*radius {
+					old := bg.NRGBAAt(x, y)
+					bg.SetNRGBA(x, y, color.NRGBA{
+						R: uint8((int(old.R) + int(fill.R)) / 2),
+						G: uint8((int(old.G) + int(fill.G)) / 2),
+						B: uint8((int(old.B) + int(fill.B)) / 2),
+						A: 255,
+					})
+				}
+			}
+		}
+	}
+	return bg, nil
+}
+
+// pieceMask 返回拼图块外接矩形内某点是否属于拼图块
+func pieceMask(size int, r int) func(x, y int) bool {
+	return func(x, y int) bool {
+		if x < 0 || y < 0 || x >= size+r || y >= size+r {
+			return false
+		}
+		if x < size && y >= r {
+			return true
+		}
+		// 上方凸起圆心 (size/2, r)，右侧凸起圆心 (size, r+size/2)
+		if dx, dy := x-size/2, y-r; dx*dx+dy*dy <= r*r {
+			return true
+		}
+		dx, dy := x-size, y-r-size/2
+		return dx*dx+dy*dy <= r*r
+	}
+}
+
+func loadImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// cover 等比缩放并居中裁剪为 w x h，使用最近邻采样
+func cover(img image.Image, w int, h int) image.Image {
+	src := img.Bounds()
+	scale := math.Max(float64(w)/float64(src.Dx()), float64(h)/float64(src.Dy()))
+	offsetX := (float64(src.Dx())*scale - float64(w)) / 2
+	offsetY := (float64(src.Dy())*scale - float64(h)) / 2
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			sx := src.Min.X + min(int((float64(x)+offsetX)/scale), src.Dx()-1)
+			sy := src.Min.Y + min(int((float64(y)+offsetY)/scale), src.Dy()-1)
+			dst.Set(x, y, img.At(sx, sy))
+		}
+	}
+	return dst
+}
+
+func encodePNG(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("编码滑动验证码图片失败: %w", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,61 @@
+package captcha
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// sliderAnswer 从缓存中读取正确的横坐标
+func sliderAnswer(t *testing.T, s *Slider, uuid string) int {
+	t.Helper()
+	var v value
+	if err := s.Client.Get(uuid, "captcha-slider", &v); err != nil {
+		t.Fatal(err)
+	}
+	x, _ := strconv.Atoi(v.Code)
+	return x
+}
+
+func TestSliderOneShot(t *testing.T) {
+	s, err := NewSlider(newTestClient(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uuid, puzzle, err := s.Generate(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if puzzle.Background == "" || puzzle.Piece == "" {
+		t.Fatal("没有生成拼图")
+	}
+	x := sliderAnswer(t, s, uuid)
+	if err := s.Verify(uuid, strconv.Itoa(x+s.Config.Tolerance+1)); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("滑动验证码默认只能验证一次，实际为 %v", err)
+	}
+	if err := s.Verify(uuid, strconv.Itoa(x)); !errors.Is(err, ErrExpired) {
+		t.Fatalf("验证失败后验证码应当作废，实际为 %v", err)
+	}
+
+	uuid, _, _ = s.Generate(time.Minute)
+	x = sliderAnswer(t, s, uuid)
+	if err := s.Verify(uuid, strconv.Itoa(x-s.Config.Tolerance)); err != nil {
+		t.Fatalf("误差范围内应当通过: %v", err)
+	}
+}
+
+func TestSliderMaxAttempts(t *testing.T) {
+	s, err := NewSlider(newTestClient(t), nil, WithMaxAttempts(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uuid, _, _ := s.Generate(time.Minute)
+	x := sliderAnswer(t, s, uuid)
+	if err := s.Verify(uuid, strconv.Itoa(x+s.Config.Tolerance+1)); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("设置了 WithMaxAttempts 时应当允许重试，实际为 %v", err)
+	}
+	if err := s.Verify(uuid, strconv.Itoa(x)); err != nil {
+		t.Fatal(err)
+	}
+}
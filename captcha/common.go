@@ -1,17 +1,120 @@
 ﻿package captcha
 
-import "math/rand"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// 验证码字符集
+const (
+	Digits      = "0123456789"
+	Alnum       = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	Unambiguous = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // 去掉了容易混淆的 0、O、1、I
+)
 
 type value struct {
-	Code    string
-	Carrier string
+	Code     string
+	Carrier  string
+	Purpose  string
+	ExpireAt time.Time
+}
+
+// CodeGenerator 生成验证码文本
+type CodeGenerator interface {
+	Generate() (string, error)
 }
 
-func generateCode() string {
-	const digits = "0123456789"
-	code := make([]byte, 6)
+// RandomCode 从字符集中随机选取字符组成验证码
+type RandomCode struct {
+	Length   int       // 长度，默认 6
+	Alphabet string    // 字符集，默认 Digits
+	Rand     io.Reader // 随机源，默认 crypto/rand.Reader
+}
+
+func (g *RandomCode) Generate() (string, error) {
+	length := g.Length
+	if length <= 0 {
+		length = 6
+	}
+	alphabet := g.Alphabet
+	if alphabet == "" {
+		alphabet = Digits
+	}
+	reader := g.Rand
+	if reader == nil {
+		reader = rand.Reader
+	}
+
+	chars := []rune(alphabet)
+	size := big.NewInt(int64(len(chars)))
+	code := make([]rune, length)
 	for i := range code {
-		code[i] = digits[rand.Intn(len(digits))]
+		n, err := rand.Int(reader, size)
+		if err != nil {
+			return "", fmt.Errorf("生成验证码失败: %w", err)
+		}
+		code[i] = chars[n.Int64()]
+	}
+	return string(code), nil
+}
+
+type options struct {
+	generator        CodeGenerator
+	maxAttempts      int
+	lockoutThreshold int
+	lockoutWindow    time.Duration
+	cooldown         time.Duration
+	carrierQuota     int
+	ipQuota          int
+	emailTemplates   *EmailTemplates
+}
+
+type Option func(*options)
+
+// WithCodeGenerator 设置验证码生成方式，默认 6 位数字，图片验证码默认与 ImageConfig.Length 等长的字母数字
+func WithCodeGenerator(g CodeGenerator) Option {
+	return func(o *options) {
+		o.generator = g
+	}
+}
+
+func newOptions(generator CodeGenerator, opts []Option) *options {
+	o := &options{generator: generator, maxAttempts: 5}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.maxAttempts <= 0 {
+		o.maxAttempts = 1
+	}
+	return o
+}
+
+// randInt 使用 crypto/rand 返回 [0, n) 之间的随机数
+func randInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return int(v.Int64()), nil
+}
+
+// expireAt 验证码的过期时间，expire<=0 表示不过期
+func expireAt(expire time.Duration) time.Time {
+	if expire <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(expire)
+}
+
+// equalCode 以固定时间比较验证码，避免通过响应时间逐位猜测；fold 为 true 时不区分大小写
+func equalCode(expected string, actual string, fold bool) bool {
+	if fold {
+		expected, actual = strings.ToUpper(expected), strings.ToUpper(actual)
 	}
-	return string(code)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
 }
@@ -2,7 +2,7 @@ package captcha
 
 import (
 	"fmt"
-	"strings"
+	"math"
 	"time"
 
 	"github.com/ligaolin/goweb/v2/cache"
@@ -10,53 +10,88 @@ import (
 )
 
 type Email struct {
-	Client *cache.Client
-	Email  *email.Email
+	Client  *cache.Client
+	Email   *email.Email
+	options *options
 }
 
-func NewEmail(c *cache.Client, e *email.Email) *Email {
+func NewEmail(c *cache.Client, e *email.Email, opts ...Option) *Email {
+	o := newOptions(&RandomCode{}, opts)
+	if o.emailTemplates == nil {
+		// 内置模板不会解析失败
+		o.emailTemplates, _ = NewEmailTemplates(&EmailTemplateConfig{})
+	}
 	return &Email{
-		Client: c,
-		Email:  e,
+		Client:  c,
+		Email:   e,
+		options: o,
 	}
 }
 
-func (e *Email) Generate(carrier string, expir time.Duration) (string, error) {
-	code := generateCode()
+// Generate 生成并发送验证码，超出发送频率限制时在发送前返回 *LimitError。
+// 通过 WithPurpose 指定用途后，只有相同用途的 Verify 才能通过
+func (e *Email) Generate(carrier string, expir time.Duration, opts ...CallOption) (string, error) {
+	c := newCall(opts)
+	limiter := e.limiter()
+	if err := limiter.allow(carrier, c); err != nil {
+		return "", err
+	}
+
+	code, err := e.options.generator.Generate()
+	if err != nil {
+		limiter.release(carrier, c, limiter.quotas(carrier, c))
+		return "", err
+	}
 	v := value{
-		Code:    code,
-		Carrier: carrier,
+		Code:     code,
+		Carrier:  carrier,
+		Purpose:  c.purpose,
+		ExpireAt: expireAt(expir),
 	}
 	uuid, err := e.Client.Set("captcha-email", v, expir)
 	if err != nil {
+		limiter.release(carrier, c, limiter.quotas(carrier, c))
 		return "", fmt.Errorf("存储邮箱验证码失败: %w", err)
 	}
-	if err := e.sendEmailCode(carrier, code, expir); err != nil {
+	if err := e.sendEmailCode(carrier, code, expir, c); err != nil {
+		limiter.release(carrier, c, nil)
 		return "", fmt.Errorf("发送邮箱验证码失败: %w", err)
 	}
 	return uuid, nil
 }
 
-func (e *Email) Verify(carrier string, uuid string, code string) error {
-	var val value
-	if err := e.Client.GetAndDelete(uuid, "captcha-email", &val); err != nil {
-		return fmt.Errorf("验证码不存在或已过期: %w", err)
-	}
-	if !strings.EqualFold(val.Code, code) {
-		return fmt.Errorf("验证码错误")
-	}
-	if val.Carrier != carrier {
-		return fmt.Errorf("不是接收验证码的邮箱")
-	}
-	return nil
+// NextAllowedAt 返回该接收账号最早可以再次发送的时间，当前即可发送时返回零值，可用于前端倒计时
+func (e *Email) NextAllowedAt(carrier string, opts ...CallOption) (time.Time, error) {
+	return e.limiter().nextAllowedAt(carrier, newCall(opts))
+}
+
+func (e *Email) limiter() *sendLimiter {
+	return &sendLimiter{client: e.Client, key: "captcha-email", options: e.options}
+}
+
+// Verify 校验验证码，错误时返回 ErrExpired、ErrMismatch、ErrCarrierMismatch、ErrPurposeMismatch 或 ErrTooManyAttempts
+func (e *Email) Verify(carrier string, uuid string, code string, opts ...CallOption) error {
+	v := &verifier{client: e.Client, key: "captcha-email", fold: true, options: e.options}
+	return v.verify(uuid, carrier, code, true, newCall(opts))
 }
 
 func (e *Email) Delete(uuid string) error {
 	return e.Client.Delete(uuid, "captcha-email")
 }
 
-func (e *Email) sendEmailCode(to string, code string, expir time.Duration) error {
-	subject := "邮箱验证码"
-	body := fmt.Sprintf("您好！\n\n您的验证码为：%s。\n此验证码有效期为 %.f 分钟，请尽快完成验证。\n\n如非本人操作，请忽略此邮件。", code, expir.Minutes())
-	return e.Email.Send([]string{to}, subject, body)
+func (e *Email) sendEmailCode(to string, code string, expir time.Duration, c *call) error {
+	subject, text, html, err := e.options.emailTemplates.Render(c.locale, EmailData{
+		Code:    code,
+		Email:   to,
+		Minutes: int(math.Round(expir.Minutes())),
+		Expire:  expir,
+	})
+	if err != nil {
+		return fmt.Errorf("渲染邮件模板失败: %w", err)
+	}
+	var opts []email.Option
+	if html != "" {
+		opts = append(opts, email.WithHTMLBody(html))
+	}
+	return e.Email.Send([]string{to}, subject, text, opts...)
 }
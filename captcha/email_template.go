@@ -0,0 +1,201 @@
+package captcha
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// EmailTemplate 一种语言的验证码邮件模板。
+// Subject、Text 使用 text/template，HTML 使用 html/template，可用变量见 EmailData
+type EmailTemplate struct {
+	Subject string
+	Text    string // 纯文本正文，为空时只发送 HTML
+	HTML    string // HTML 正文，为空时只发送纯文本
+}
+
+type EmailTemplateConfig struct {
+	DefaultLocale string                    // 无法匹配时使用的语言，默认 zh-CN
+	Locales       map[string]*EmailTemplate // 按语言区分的模板，键为 BCP 47 语言标签，为空时使用内置的中文、英文模板
+	Product       string                    // 产品名称
+	SupportURL    string                    // 帮助或客服链接
+}
+
+// EmailData 渲染邮件模板时的数据
+type EmailData struct {
+	Code       string
+	Email      string
+	Minutes    int // 有效期，分钟
+	Expire     time.Duration
+	Product    string
+	SupportURL string
+	Locale     string
+}
+
+// EmailTemplates 编译后的验证码邮件模板
+type EmailTemplates struct {
+	config  *EmailTemplateConfig
+	locales map[string]*emailTemplate
+	matcher language.Matcher
+	tags    []string // 与 matcher 的候选语言一一对应，第一个为默认语言
+}
+
+type emailTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+func NewEmailTemplates(config *EmailTemplateConfig) (*EmailTemplates, error) {
+	if config.DefaultLocale == "" {
+		config.DefaultLocale = "zh-CN"
+	}
+	if len(config.Locales) == 0 {
+		config.Locales = defaultEmailTemplates
+	}
+	if _, ok := config.Locales[config.DefaultLocale]; !ok {
+		return nil, fmt.Errorf("缺少默认语言 %s 的邮件模板", config.DefaultLocale)
+	}
+
+	t := &EmailTemplates{
+		config:  config,
+		locales: make(map[string]*emailTemplate, len(config.Locales)),
+		tags:    []string{config.DefaultLocale},
+	}
+	for locale := range config.Locales {
+		if locale != config.DefaultLocale {
+			t.tags = append(t.tags, locale)
+		}
+	}
+	slices.Sort(t.tags[1:])
+
+	supported := make([]language.Tag, len(t.tags))
+	for i, locale := range t.tags {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("无效的语言标签 %s: %w", locale, err)
+		}
+		supported[i] = tag
+
+		tpl := config.Locales[locale]
+		compiled := &emailTemplate{}
+		if compiled.subject, err = texttemplate.New("subject").Parse(tpl.Subject); err != nil {
+			return nil, fmt.Errorf("解析 %s 邮件主题模板失败: %w", locale, err)
+		}
+		if tpl.Text != "" {
+			if compiled.text, err = texttemplate.New("text").Parse(tpl.Text); err != nil {
+				return nil, fmt.Errorf("解析 %s 邮件正文模板失败: %w", locale, err)
+			}
+		}
+		if tpl.HTML != "" {
+			if compiled.html, err = htmltemplate.New("html").Parse(tpl.HTML); err != nil {
+				return nil, fmt.Errorf("解析 %s 邮件HTML模板失败: %w", locale, err)
+			}
+		}
+		if compiled.text == nil && compiled.html == nil {
+			return nil, fmt.Errorf("%s 邮件模板缺少正文", locale)
+		}
+		t.locales[locale] = compiled
+	}
+	t.matcher = language.NewMatcher(supported)
+	return t, nil
+}
+
+// Match 根据 Accept-Language 格式的语言偏好选择模板语言，例如 "en-US,en;q=0.9"
+func (t *EmailTemplates) Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return t.tags[0]
+	}
+	_, i, confidence := t.matcher.Match(tags...)
+	if confidence == language.No {
+		return t.tags[0]
+	}
+	return t.tags[i]
+}
+
+// Render 渲染邮件主题、纯文本正文和 HTML 正文
+func (t *EmailTemplates) Render(acceptLanguage string, data EmailData) (subject string, text string, html string, err error) {
+	data.Locale = t.Match(acceptLanguage)
+	data.Product = t.config.Product
+	data.SupportURL = t.config.SupportURL
+	tpl := t.locales[data.Locale]
+
+	var buf bytes.Buffer
+	if err := tpl.subject.Execute(&buf, data); err != nil {
+		return "", "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+	if tpl.text != nil {
+		buf.Reset()
+		if err := tpl.text.Execute(&buf, data); err != nil {
+			return "", "", "", err
+		}
+		text = buf.String()
+	}
+	if tpl.html != nil {
+		buf.Reset()
+		if err := tpl.html.Execute(&buf, data); err != nil {
+			return "", "", "", err
+		}
+		html = buf.String()
+	}
+	return subject, text, html, nil
+}
+
+// WithEmailTemplates 设置验证码邮件模板，默认使用内置的中文、英文模板
+func WithEmailTemplates(t *EmailTemplates) Option {
+	return func(o *options) {
+		o.emailTemplates = t
+	}
+}
+
+// WithLocale 设置邮件语言偏好，格式同 Accept-Language 请求头
+func WithLocale(acceptLanguage string) CallOption {
+	return func(c *call) {
+		c.locale = acceptLanguage
+	}
+}
+
+var defaultEmailTemplates = map[string]*EmailTemplate{
+	"zh-CN": {
+		Subject: `{{if .Product}}{{.Product}} {{end}}邮箱验证码`,
+		Text: `您好！
+
+您的验证码为：{{.Code}}。
+此验证码有效期为 {{.Minutes}} 分钟，请尽快完成验证。
+
+如非本人操作，请忽略此邮件。{{if .SupportURL}}
+如有疑问请访问 {{.SupportURL}}{{end}}`,
+		HTML: `<div style="font-family:sans-serif;line-height:1.6">
+<p>您好！</p>
+<p>您的{{if .Product}} {{.Product}} {{end}}验证码为：</p>
+<p style="font-size:28px;font-weight:bold;letter-spacing:4px">{{.Code}}</p>
+<p>此验证码有效期为 {{.Minutes}} 分钟，请尽快完成验证。</p>
+<p style="color:#888">如非本人操作，请忽略此邮件。{{if .SupportURL}}如有疑问请访问 <a href="{{.SupportURL}}">{{.SupportURL}}</a>{{end}}</p>
+</div>`,
+	},
+	"en": {
+		Subject: `{{if .Product}}{{.Product}} {{end}}verification code`,
+		Text: `Hello,
+
+Your verification code is: {{.Code}}
+It expires in {{.Minutes}} minutes.
+
+If you did not request this code, please ignore this email.{{if .SupportURL}}
+Need help? Visit {{.SupportURL}}{{end}}`,
+		HTML: `<div style="font-family:sans-serif;line-height:1.6">
+<p>Hello,</p>
+<p>Your{{if .Product}} {{.Product}}{{end}} verification code is:</p>
+<p style="font-size:28px;font-weight:bold;letter-spacing:4px">{{.Code}}</p>
+<p>It expires in {{.Minutes}} minutes.</p>
+<p style="color:#888">If you did not request this code, please ignore this email.{{if .SupportURL}} Need help? Visit <a href="{{.SupportURL}}">{{.SupportURL}}</a>{{end}}</p>
+</div>`,
+	},
+}
@@ -0,0 +1,98 @@
+package captcha
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/email"
+)
+
+// startSMTP 启动只接收邮件、不做任何校验的 SMTP 服务，返回端口
+func startSMTP(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn)
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func serveSMTP(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	reply("220 localhost")
+	data := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if data {
+			if line == "." {
+				data = false
+				reply("250 OK")
+			}
+			continue
+		}
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "DATA":
+			data = true
+			reply("354 go ahead")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailPurpose(t *testing.T) {
+	sender, err := email.New(&email.EmailConfig{
+		Smtp:     "127.0.0.1",
+		Port:     startSMTP(t),
+		Email:    "noreply@example.com",
+		Password: "password",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient(t)
+	e := NewEmail(client, sender)
+	uuid, err := e.Generate("user@example.com", time.Minute, WithPurpose("login"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v value
+	if err := client.Get(uuid, "captcha-email", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Purpose != "login" {
+		t.Fatalf("Purpose = %q", v.Purpose)
+	}
+
+	if err := e.Verify("user@example.com", uuid, v.Code, WithPurpose("reset-password")); !errors.Is(err, ErrPurposeMismatch) {
+		t.Fatalf("用途不同应当返回 ErrPurposeMismatch，实际为 %v", err)
+	}
+	if err := e.Verify("user@example.com", uuid, v.Code, WithPurpose("login")); err != nil {
+		t.Fatalf("用途一致时应当验证通过: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/captcha"
+	"github.com/ligaolin/goweb/v2/ratelimit"
+	"github.com/ligaolin/goweb/v2/response"
+	"github.com/ligaolin/goweb/v2/validator"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// Handler 验证码相关接口，未设置的验证码类型对应的接口返回 404
+type Handler struct {
+	Image    *captcha.Image
+	Slider   *captcha.Slider
+	Sms      *captcha.Sms
+	Email    *captcha.Email
+	Expire   time.Duration                // 验证码有效期，默认 5 分钟
+	ClientIP func(r *http.Request) string // 获取客户端 IP，默认 ratelimit.KeyByIP 只取连接的对端地址，部署在反向代理之后时使用 ratelimit.IPResolver 的 ClientIP
+}
+
+func New(h *Handler) *Handler {
+	if h.Expire <= 0 {
+		h.Expire = 5 * time.Minute
+	}
+	if h.ClientIP == nil {
+		h.ClientIP = ratelimit.KeyByIP
+	}
+	return h
+}
+
+// Register 将接口挂载到 mux，例如 prefix 为 /captcha 时：
+// GET /captcha/image、GET /captcha/slider、POST /captcha/sms、POST /captcha/email、POST /captcha/verify
+func (h *Handler) Register(mux *http.ServeMux, prefix string) {
+	mux.HandleFunc("GET "+prefix+"/image", h.GenerateImage)
+	mux.HandleFunc("GET "+prefix+"/slider", h.GenerateSlider)
+	mux.HandleFunc("POST "+prefix+"/sms", h.SendSms)
+	mux.HandleFunc("POST "+prefix+"/email", h.SendEmail)
+	mux.HandleFunc("POST "+prefix+"/verify", h.Verify)
+}
+
+// GenerateImage 生成图片（或语音）验证码，返回 captcha_id 和 data URI 格式的 image
+func (h *Handler) GenerateImage(w http.ResponseWriter, r *http.Request) {
+	if h.Image == nil {
+		notFound(w)
+		return
+	}
+	id, b64s, err := h.Image.Generate(h.Expire)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	response.NewResponse(w).SetData(map[string]any{
+		"captcha_id": id,
+		"image":      b64s,
+	}).Write()
+}
+
+// GenerateSlider 生成滑动拼图验证码，返回 captcha_id 和 puzzle
+func (h *Handler) GenerateSlider(w http.ResponseWriter, r *http.Request) {
+	if h.Slider == nil {
+		notFound(w)
+		return
+	}
+	id, puzzle, err := h.Slider.Generate(h.Expire)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	response.NewResponse(w).SetData(map[string]any{
+		"captcha_id": id,
+		"puzzle":     puzzle,
+	}).Write()
+}
+
+type smsParam struct {
+	Phone string `json:"phone" form:"phone" validate:"required:请输入手机号;mobile:手机号格式不正确"`
+}
+
+// SendSms 发送短信验证码，返回 captcha_id
+func (h *Handler) SendSms(w http.ResponseWriter, r *http.Request) {
+	if h.Sms == nil {
+		notFound(w)
+		return
+	}
+	var param smsParam
+	if err := validator.NewRequest(&param).Bind(r).Validate().Error; err != nil {
+		badRequest(w, err)
+		return
+	}
+	id, err := h.Sms.Generate(param.Phone, h.Expire, captcha.WithContext(r.Context()), captcha.WithIP(h.ClientIP(r)))
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	response.NewResponse(w).SetData(map[string]any{"captcha_id": id}).Write()
+}
+
+type emailParam struct {
+	Email string `json:"email" form:"email" validate:"required:请输入邮箱;email:邮箱格式不正确"`
+}
+
+// SendEmail 发送邮箱验证码，邮件语言取自 Accept-Language 请求头，返回 captcha_id
+func (h *Handler) SendEmail(w http.ResponseWriter, r *http.Request) {
+	if h.Email == nil {
+		notFound(w)
+		return
+	}
+	var param emailParam
+	if err := validator.NewRequest(&param).Bind(r).Validate().Error; err != nil {
+		badRequest(w, err)
+		return
+	}
+	id, err := h.Email.Generate(param.Email, h.Expire,
+		captcha.WithContext(r.Context()),
+		captcha.WithIP(h.ClientIP(r)),
+		captcha.WithLocale(r.Header.Get("Accept-Language")),
+	)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	response.NewResponse(w).SetData(map[string]any{"captcha_id": id}).Write()
+}
+
+type verifyParam struct {
+	Kind string `json:"kind" form:"kind" validate:"required:请指定验证码类型;in=image,slider,sms,email:不支持的验证码类型"`
+	Param
+}
+
+// Verify 校验验证码，验证成功后验证码即作废
+func (h *Handler) Verify(w http.ResponseWriter, r *http.Request) {
+	var param verifyParam
+	if err := validator.NewRequest(&param).Bind(r).Validate().Error; err != nil {
+		badRequest(w, err)
+		return
+	}
+	if err := validator.Validator(&param.Param); err != nil {
+		badRequest(w, err)
+		return
+	}
+	err := h.verify(r, param.Kind, &param.Param)
+	if errors.Is(err, errDisabled) {
+		notFound(w)
+		return
+	}
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	response.NewResponse(w).Write()
+}
+
+var errDisabled = errors.New("未启用该类型的验证码")
+
+func (h *Handler) verify(r *http.Request, kind string, p *Param) error {
+	opts := []captcha.CallOption{captcha.WithContext(r.Context()), captcha.WithIP(h.ClientIP(r))}
+	switch kind {
+	case "image":
+		if h.Image != nil {
+			return h.Image.Verify(p.ID, p.Code, opts...)
+		}
+	case "slider":
+		if h.Slider != nil {
+			return h.Slider.Verify(p.ID, p.Code, opts...)
+		}
+	case "sms":
+		if h.Sms != nil {
+			return h.Sms.Verify(p.Phone, p.ID, p.Code, opts...)
+		}
+	case "email":
+		if h.Email != nil {
+			return h.Email.Verify(p.Email, p.ID, p.Code, opts...)
+		}
+	}
+	return errDisabled
+}
+
+// fail 输出验证码错误，未知错误只记录日志，不把内部错误返回给客户端
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	code := captcha.StatusCode(err)
+	res := response.NewResponse(w).SetCode(code).SetMessage(err.Error())
+	var limit *captcha.LimitError
+	if errors.As(err, &limit) {
+		res.SetData(map[string]any{"next_allowed_at": limit.NextAllowedAt.Unix()})
+	}
+	if code == http.StatusInternalServerError {
+		logc.Errorf(r.Context(), "验证码处理失败: %v", err)
+		res.SetMessage("验证码服务异常，请稍后再试")
+	}
+	res.Write()
+}
+
+func badRequest(w http.ResponseWriter, err error) {
+	response.NewResponse(w).SetCode(http.StatusBadRequest).SetMessage(err.Error()).Write()
+}
+
+func notFound(w http.ResponseWriter) {
+	response.NewResponse(w).SetCode(http.StatusNotFound).SetMessage(errDisabled.Error()).Write()
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ligaolin/goweb/v2/response"
+	"github.com/ligaolin/goweb/v2/validator"
+)
+
+// Param 请求中携带的验证码字段，Phone、Email 只在短信、邮箱验证码中使用
+type Param struct {
+	ID    string `json:"captcha_id" form:"captcha_id" validate:"required:缺少验证码ID"`
+	Code  string `json:"captcha_code" form:"captcha_code" validate:"required:请输入验证码"`
+	Phone string `json:"phone" form:"phone"`
+	Email string `json:"email" form:"email"`
+}
+
+// maxBodySize RequireCaptcha 读取请求体的上限
+const maxBodySize = 1 << 20
+
+// RequireCaptcha 在执行 next 之前校验请求中的 captcha_id、captcha_code，
+// kind 为 image、slider、sms、email，短信和邮箱验证码还会读取 phone、email 字段校验接收账号。
+// 请求体会被缓存并还原，后续处理器仍然可以正常绑定参数，超过 1MB 时返回 413
+func (h *Handler) RequireCaptcha(kind string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var param Param
+			if err := bindParam(w, r, &param); err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					response.NewResponse(w).SetCode(http.StatusRequestEntityTooLarge).SetMessage("请求体过大").Write()
+					return
+				}
+				badRequest(w, err)
+				return
+			}
+			if err := h.verify(r, kind, &param); err != nil {
+				if errors.Is(err, errDisabled) {
+					notFound(w)
+					return
+				}
+				h.fail(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bindParam 从请求的副本中绑定验证码字段，不消耗原请求体，请求体超过 maxBodySize 时返回 *http.MaxBytesError
+func bindParam(w http.ResponseWriter, r *http.Request, param *Param) error {
+	clone := r.Clone(r.Context())
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		r.Body.Close()
+		if err != nil {
+			return err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		clone.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return validator.NewRequest(param).Bind(clone).Validate().Error
+}
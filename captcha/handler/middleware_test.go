@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/cache"
+	"github.com/ligaolin/goweb/v2/captcha"
+)
+
+// responseCode 返回响应体中的 code
+func responseCode(t *testing.T, rec *httptest.ResponseRecorder) int {
+	t.Helper()
+	var res struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("解析响应失败: %v, %s", err, rec.Body)
+	}
+	return res.Code
+}
+
+func newTestHandler(t *testing.T) (*Handler, *cache.Client) {
+	t.Helper()
+	memory := cache.NewMemory(nil)
+	t.Cleanup(func() { memory.Close() })
+	client := cache.NewClient(memory)
+	return New(&Handler{Image: captcha.NewImage(client, &captcha.ImageConfig{Type: "math"})}), client
+}
+
+func TestRequireCaptcha(t *testing.T) {
+	h, client := newTestHandler(t)
+	id, _, err := h.Image.Generate(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var answer struct{ Code string }
+	if err := client.Get(id, "captcha-image-math", &answer); err != nil {
+		t.Fatal(err)
+	}
+
+	var received string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+	})
+	body := `{"captcha_id":"` + id + `","captcha_code":"` + answer.Code + `","name":"test"}`
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.RequireCaptcha("image")(next).ServeHTTP(rec, r)
+	if received != body {
+		t.Fatalf("后续处理器读取到的请求体为 %q, 响应 %s", received, rec.Body)
+	}
+}
+
+func TestRequireCaptchaBodyTooLarge(t *testing.T) {
+	h, _ := newTestHandler(t)
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	// 超出上限的请求体不能被截断后继续解析
+	body := `{"captcha_id":"x","captcha_code":"y","pad":"` + strings.Repeat("a", maxBodySize) + `"}`
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.RequireCaptcha("image")(next).ServeHTTP(rec, r)
+	if called {
+		t.Fatal("请求体过大时不应执行后续处理器")
+	}
+	if code := responseCode(t, rec); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("code = %d，应当为 413", code)
+	}
+}
+
+func TestRequireCaptchaDisabled(t *testing.T) {
+	h, _ := newTestHandler(t)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("未启用的验证码类型不应执行后续处理器")
+	})
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"captcha_id":"x","captcha_code":"y"}`))
+	r.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.RequireCaptcha("sms")(next).ServeHTTP(rec, r)
+	if code := responseCode(t, rec); code != http.StatusNotFound {
+		t.Fatalf("code = %d，应当为 404", code)
+	}
+}
+
+func TestDefaultClientIP(t *testing.T) {
+	h, _ := newTestHandler(t)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:5000"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1")
+	if ip := h.ClientIP(r); ip != "203.0.113.7" {
+		t.Fatalf("ClientIP = %s，默认不应信任请求头", ip)
+	}
+}
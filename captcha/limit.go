@@ -0,0 +1,152 @@
+package captcha
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/cache"
+)
+
+var (
+	ErrCooldown   = errors.New("发送过于频繁，请稍后再试")
+	ErrDailyQuota = errors.New("今日发送次数已达上限")
+)
+
+// LimitError 发送受限时返回，可通过 errors.Is 判断是 ErrCooldown 还是 ErrDailyQuota
+type LimitError struct {
+	Err           error
+	NextAllowedAt time.Time // 最早可以再次发送的时间
+}
+
+func (e *LimitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// WithCooldown 同一接收账号两次发送之间的最小间隔，默认不限制
+func WithCooldown(d time.Duration) Option {
+	return func(o *options) {
+		o.cooldown = d
+	}
+}
+
+// WithDailyQuota 每个接收账号、每个 IP 每天最多发送的次数，0 表示不限制
+func WithDailyQuota(perCarrier int, perIP int) Option {
+	return func(o *options) {
+		o.carrierQuota = perCarrier
+		o.ipQuota = perIP
+	}
+}
+
+// sendLimiter 发送频率限制，状态保存在 cache.Client 中
+type sendLimiter struct {
+	client  *cache.Client
+	key     string
+	options *options
+}
+
+// allow 检查并占用发送额度，超出限制时返回 *LimitError
+func (l *sendLimiter) allow(carrier string, c *call) error {
+	if next, err := l.nextAllowedAt(carrier, c); err != nil {
+		return err
+	} else if !next.IsZero() {
+		return l.limitError(carrier, c, next)
+	}
+
+	if l.options.cooldown > 0 {
+		until := time.Now().Add(l.options.cooldown)
+		ok, err := l.client.SetNX(c.ctx, carrier, l.key+":cooldown", until, l.options.cooldown)
+		if err != nil {
+			return fmt.Errorf("记录发送间隔失败: %w", err)
+		}
+		if !ok {
+			// 并发请求先写入了发送间隔
+			next, _ := l.nextAllowedAt(carrier, c)
+			return &LimitError{Err: ErrCooldown, NextAllowedAt: next}
+		}
+	}
+	quotas := l.quotas(carrier, c)
+	for i, q := range quotas {
+		n, err := l.client.Incr(c.ctx, q.id, q.key, 1, time.Until(q.reset)+time.Minute)
+		if err != nil {
+			l.release(carrier, c, quotas[:i])
+			return fmt.Errorf("记录发送次数失败: %w", err)
+		}
+		if n > int64(q.limit) {
+			l.release(carrier, c, quotas[:i+1])
+			return &LimitError{Err: ErrDailyQuota, NextAllowedAt: q.reset}
+		}
+	}
+	return nil
+}
+
+// release 解除发送间隔限制并退回 quotas 中已计入的次数，发送失败时只解除发送间隔
+func (l *sendLimiter) release(carrier string, c *call, quotas []quota) {
+	if l.options.cooldown > 0 {
+		l.client.Delete(carrier, l.key+":cooldown")
+	}
+	for _, q := range quotas {
+		l.client.Incr(c.ctx, q.id, q.key, -1, 0)
+	}
+}
+
+// nextAllowedAt 返回最早可以发送的时间，当前即可发送时返回零值
+func (l *sendLimiter) nextAllowedAt(carrier string, c *call) (time.Time, error) {
+	var next time.Time
+	for _, q := range l.quotas(carrier, c) {
+		n, err := l.client.Counter(c.ctx, q.id, q.key)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("读取发送次数失败: %w", err)
+		}
+		if n >= int64(q.limit) && q.reset.After(next) {
+			next = q.reset
+		}
+	}
+	if l.options.cooldown > 0 {
+		var until time.Time
+		err := l.client.Get(carrier, l.key+":cooldown", &until)
+		if err != nil && !errors.Is(err, cache.ErrNotFound) {
+			return time.Time{}, fmt.Errorf("读取发送间隔失败: %w", err)
+		}
+		if until.After(time.Now()) && until.After(next) {
+			next = until
+		}
+	}
+	return next, nil
+}
+
+func (l *sendLimiter) limitError(carrier string, c *call, next time.Time) error {
+	for _, q := range l.quotas(carrier, c) {
+		if next.Equal(q.reset) {
+			return &LimitError{Err: ErrDailyQuota, NextAllowedAt: next}
+		}
+	}
+	return &LimitError{Err: ErrCooldown, NextAllowedAt: next}
+}
+
+type quota struct {
+	id    string
+	key   string
+	limit int
+	reset time.Time
+}
+
+// quotas 返回当天需要检查的每日额度，次日零点重置
+func (l *sendLimiter) quotas(carrier string, c *call) []quota {
+	now := time.Now()
+	day := now.Format("20060102")
+	reset := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+
+	var quotas []quota
+	if l.options.carrierQuota > 0 {
+		quotas = append(quotas, quota{carrier, l.key + ":daily:" + day, l.options.carrierQuota, reset})
+	}
+	if l.options.ipQuota > 0 && c.ip != "" {
+		quotas = append(quotas, quota{c.ip, l.key + ":daily_ip:" + day, l.options.ipQuota, reset})
+	}
+	return quotas
+}
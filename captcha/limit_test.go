@@ -0,0 +1,60 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/cache"
+	"github.com/ligaolin/goweb/v2/sms"
+)
+
+func TestSendLimits(t *testing.T) {
+	memory := cache.NewMemory(nil)
+	defer memory.Close()
+	fake := sms.NewFake()
+	s := NewSms(cache.NewClient(memory), fake, "tpl", WithDailyQuota(2, 0))
+
+	// 查询不应创建计数器
+	if next, err := s.NextAllowedAt("13800000000"); err != nil || !next.IsZero() {
+		t.Fatalf("NextAllowedAt = %v, %v", next, err)
+	}
+	key := "client_captcha-sms:daily:" + time.Now().Format("20060102") + "_13800000000"
+	if ok, _ := memory.Exists(context.Background(), key); ok {
+		t.Fatal("查询发送时间不应写入缓存")
+	}
+
+	for range 2 {
+		if _, err := s.Generate("13800000000", time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := s.Generate("13800000000", time.Minute)
+	var limitErr *LimitError
+	if !errors.Is(err, ErrDailyQuota) || !errors.As(err, &limitErr) || limitErr.NextAllowedAt.IsZero() {
+		t.Fatalf("超过每日额度应当返回 ErrDailyQuota，实际为 %v", err)
+	}
+	if ok, _ := memory.Exists(context.Background(), key); !ok {
+		t.Fatal("发送后应当记录发送次数")
+	}
+	if len(fake.Messages) != 2 {
+		t.Fatalf("发送了 %d 条短信", len(fake.Messages))
+	}
+}
+
+func TestSendCooldown(t *testing.T) {
+	s := NewSms(newTestClient(t), sms.NewFake(), "tpl", WithCooldown(time.Minute))
+	if _, err := s.Generate("13800000000", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Generate("13800000000", time.Minute); !errors.Is(err, ErrCooldown) {
+		t.Fatalf("发送间隔内应当返回 ErrCooldown，实际为 %v", err)
+	}
+	if next, _ := s.NextAllowedAt("13800000000"); time.Until(next) <= 0 {
+		t.Fatal("发送间隔内 NextAllowedAt 应当在未来")
+	}
+	if _, err := s.Generate("13900000000", time.Minute); err != nil {
+		t.Fatalf("其他接收账号不受影响: %v", err)
+	}
+}
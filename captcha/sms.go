@@ -2,68 +2,76 @@ package captcha
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
-	dysmsapi20170525 "github.com/alibabacloud-go/dysmsapi-20170525/v5/client"
-	"github.com/alibabacloud-go/tea-utils/v2/service"
-	"github.com/alibabacloud-go/tea/tea"
 	"github.com/ligaolin/goweb/v2/cache"
-	"github.com/ligaolin/goweb/v2/sdk/ali"
+	"github.com/ligaolin/goweb/v2/sms"
 )
 
 type Sms struct {
-	Client *cache.Client
-	AliSms *ali.AliSms
+	Client     *cache.Client
+	Sender     sms.Sender
+	TemplateID string // 验证码短信模板，模板参数为 code
+	options    *options
 }
 
-func NewSms(c *cache.Client, a *ali.AliSms) *Sms {
+// NewSms 创建短信验证码，阿里云短信使用 sms.NewAli，templateID 为空时使用 AliSmsConfig.TemplateCodeVerificationCode
+func NewSms(c *cache.Client, sender sms.Sender, templateID string, opts ...Option) *Sms {
 	return &Sms{
-		Client: c,
-		AliSms: a,
+		Client:     c,
+		Sender:     sender,
+		TemplateID: templateID,
+		options:    newOptions(&RandomCode{}, opts),
 	}
 }
 
-func (s *Sms) Generate(carrier string, expir time.Duration) (string, error) {
-	code := generateCode()
+// Generate 生成并发送验证码，超出发送频率限制时在发送前返回 *LimitError。
+// 通过 WithPurpose 指定用途后，只有相同用途的 Verify 才能通过
+func (s *Sms) Generate(carrier string, expir time.Duration, opts ...CallOption) (string, error) {
+	c := newCall(opts)
+	limiter := s.limiter()
+	if err := limiter.allow(carrier, c); err != nil {
+		return "", err
+	}
+
+	code, err := s.options.generator.Generate()
+	if err != nil {
+		limiter.release(carrier, c, limiter.quotas(carrier, c))
+		return "", err
+	}
 	v := value{
-		Code:    code,
-		Carrier: carrier,
+		Code:     code,
+		Carrier:  carrier,
+		Purpose:  c.purpose,
+		ExpireAt: expireAt(expir),
 	}
 	uuid, err := s.Client.Set("captcha-sms", v, expir)
 	if err != nil {
+		limiter.release(carrier, c, limiter.quotas(carrier, c))
 		return "", fmt.Errorf("存储短信验证码失败: %w", err)
 	}
-	if err := s.sendSmsCode(carrier, code); err != nil {
+	if err := s.Sender.Send(c.ctx, carrier, s.TemplateID, map[string]string{"code": code}); err != nil {
+		limiter.release(carrier, c, nil)
 		return "", fmt.Errorf("发送短信验证码失败: %w", err)
 	}
 	return uuid, nil
 }
 
-func (s *Sms) Verify(carrier string, uuid string, code string) error {
-	var val value
-	if err := s.Client.GetAndDelete(uuid, "captcha-sms", &val); err != nil {
-		return fmt.Errorf("验证码不存在或已过期: %w", err)
-	}
-	if !strings.EqualFold(val.Code, code) {
-		return fmt.Errorf("验证码错误")
-	}
-	if val.Carrier != carrier {
-		return fmt.Errorf("不是接收验证码的手机号")
-	}
-	return nil
+// NextAllowedAt 返回该接收账号最早可以再次发送的时间，当前即可发送时返回零值，可用于前端倒计时
+func (s *Sms) NextAllowedAt(carrier string, opts ...CallOption) (time.Time, error) {
+	return s.limiter().nextAllowedAt(carrier, newCall(opts))
 }
 
-func (s *Sms) Delete(uuid string) error {
-	return s.Client.Delete(uuid, "captcha-sms")
+func (s *Sms) limiter() *sendLimiter {
+	return &sendLimiter{client: s.Client, key: "captcha-sms", options: s.options}
+}
+
+// Verify 校验验证码，错误时返回 ErrExpired、ErrMismatch、ErrCarrierMismatch、ErrPurposeMismatch 或 ErrTooManyAttempts
+func (s *Sms) Verify(carrier string, uuid string, code string, opts ...CallOption) error {
+	v := &verifier{client: s.Client, key: "captcha-sms", fold: true, options: s.options}
+	return v.verify(uuid, carrier, code, true, newCall(opts))
 }
 
-func (s *Sms) sendSmsCode(mobile string, code string) error {
-	_, err := s.AliSms.Client.SendSmsWithOptions(&dysmsapi20170525.SendSmsRequest{
-		PhoneNumbers:  tea.String(mobile),
-		SignName:      tea.String(s.AliSms.Config.SignName),
-		TemplateCode:  tea.String(s.AliSms.Config.TemplateCodeVerificationCode),
-		TemplateParam: tea.String(fmt.Sprintf(`{"code":"%s"}`, code)),
-	}, &service.RuntimeOptions{})
-	return err
+func (s *Sms) Delete(uuid string) error {
+	return s.Client.Delete(uuid, "captcha-sms")
 }
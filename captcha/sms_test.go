@@ -0,0 +1,32 @@
+package captcha
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/sms"
+)
+
+func TestSmsPurpose(t *testing.T) {
+	fake := sms.NewFake()
+	s := NewSms(newTestClient(t), fake, "tpl")
+	uuid, err := s.Generate("13800000000", time.Minute, WithPurpose("login"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := fake.Messages[0].Params["code"]
+
+	if err := s.Verify("13800000000", uuid, code, WithPurpose("reset-password")); !errors.Is(err, ErrPurposeMismatch) {
+		t.Fatalf("用途不同应当返回 ErrPurposeMismatch，实际为 %v", err)
+	}
+	if err := s.Verify("13800000000", uuid, code); !errors.Is(err, ErrPurposeMismatch) {
+		t.Fatalf("未指定用途应当返回 ErrPurposeMismatch，实际为 %v", err)
+	}
+	if err := s.Verify("13900000000", uuid, code, WithPurpose("login")); !errors.Is(err, ErrCarrierMismatch) {
+		t.Fatalf("接收账号不同应当返回 ErrCarrierMismatch，实际为 %v", err)
+	}
+	if err := s.Verify("13800000000", uuid, code, WithPurpose("login")); err != nil {
+		t.Fatalf("用途一致时应当验证通过: %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/cache"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+var (
+	ErrExpired         = errors.New("验证码不存在或已过期")
+	ErrMismatch        = errors.New("验证码错误")
+	ErrTooManyAttempts = errors.New("验证失败次数过多，请稍后再试")
+	ErrCarrierMismatch = errors.New("不是接收验证码的账号")
+	ErrPurposeMismatch = errors.New("验证码用途不匹配")
+)
+
+// StatusCode 将验证、发送限制错误转换为 HTTP 状态码，便于处理器统一返回
+func StatusCode(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrTooManyAttempts), errors.Is(err, ErrCooldown), errors.Is(err, ErrDailyQuota):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrExpired), errors.Is(err, ErrMismatch), errors.Is(err, ErrCarrierMismatch), errors.Is(err, ErrPurposeMismatch):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// WithMaxAttempts 每个验证码允许的验证次数，默认 5，滑动验证码默认 1，用完后验证码作废
+func WithMaxAttempts(n int) Option {
+	return func(o *options) {
+		o.maxAttempts = n
+	}
+}
+
+// WithLockout 同一接收账号或同一 IP 在 window 内验证失败 threshold 次后锁定，直到 window 结束，
+// window 默认 15 分钟；默认不锁定
+func WithLockout(threshold int, window time.Duration) Option {
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+	return func(o *options) {
+		o.lockoutThreshold = threshold
+		o.lockoutWindow = window
+	}
+}
+
+type call struct {
+	ctx     context.Context
+	ip      string
+	locale  string
+	purpose string
+}
+
+// CallOption 单次调用的参数
+type CallOption func(*call)
+
+// WithContext 设置调用使用的 context
+func WithContext(ctx context.Context) CallOption {
+	return func(c *call) {
+		c.ctx = ctx
+	}
+}
+
+// WithIP 设置客户端 IP，用于按 IP 锁定
+func WithIP(ip string) CallOption {
+	return func(c *call) {
+		c.ip = ip
+	}
+}
+
+// WithPurpose 设置验证码用途，例如 login、reset-password，生成和验证时的用途必须一致
+func WithPurpose(purpose string) CallOption {
+	return func(c *call) {
+		c.purpose = purpose
+	}
+}
+
+func newCall(opts []CallOption) *call {
+	c := &call{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// verifier 验证码校验：失败计数与验证码一同保存在缓存中，验证成功后验证码立即作废
+type verifier struct {
+	client  *cache.Client
+	key     string // 验证码在缓存中的键
+	fold    bool   // 不区分大小写
+	options *options
+	match   func(expected string, actual string) bool // 自定义比较，为空时按验证码文本比较
+	dst     any                                       // 验证成功时取出完整的缓存值，为空时只取 value
+}
+
+// verify 比较前先原子地占用一次验证次数和失败次数，并发请求不会超出 maxAttempts 与 lockoutThreshold；
+// 后端没有实现 cache.Store 时无法计数，只比较验证码
+func (v *verifier) verify(uuid string, carrier string, code string, checkCarrier bool, c *call) error {
+	_, counting := v.client.Cache.(cache.Store)
+	var reserved []counterID
+	if counting {
+		var err error
+		if reserved, err = v.reserveLockout(carrier, c); err != nil {
+			return err
+		}
+	}
+
+	var val value
+	if err := v.client.Get(uuid, v.key, &val); err != nil {
+		v.release(reserved, c)
+		if errors.Is(err, cache.ErrNotFound) {
+			return ErrExpired
+		}
+		return fmt.Errorf("读取验证码失败: %w", err)
+	}
+
+	var attempts int64
+	if counting {
+		ttl := time.Until(val.ExpireAt)
+		if val.ExpireAt.IsZero() || ttl <= 0 {
+			ttl = 10 * time.Minute
+		}
+		var err error
+		attempts, err = v.client.Incr(c.ctx, uuid, v.key+":attempts", 1, ttl)
+		if err != nil {
+			v.release(reserved, c)
+			return fmt.Errorf("记录验证次数失败: %w", err)
+		}
+		if attempts > int64(v.options.maxAttempts) {
+			return v.discard(uuid)
+		}
+	}
+
+	var mismatch error
+	switch {
+	case val.Purpose != c.purpose:
+		mismatch = ErrPurposeMismatch
+	case checkCarrier && val.Carrier != carrier:
+		mismatch = ErrCarrierMismatch
+	case v.match != nil && !v.match(val.Code, code), v.match == nil && !equalCode(val.Code, code, v.fold):
+		mismatch = ErrMismatch
+	}
+	if mismatch != nil {
+		// 占用的失败次数保留，作为这次失败的记录
+		if counting && attempts >= int64(v.options.maxAttempts) {
+			return v.discard(uuid)
+		}
+		return mismatch
+	}
+
+	// 并发验证时只有一个请求能够取出验证码
+	var dst any = &val
+	if v.dst != nil {
+		dst = v.dst
+	}
+	if err := v.client.GetAndDelete(uuid, v.key, dst); err != nil {
+		v.release(reserved, c)
+		if errors.Is(err, cache.ErrNotFound) {
+			return ErrExpired
+		}
+		return fmt.Errorf("删除验证码失败: %w", err)
+	}
+
+	// 验证码已经取出，清理计数失败不影响验证结果，计数器会随有效期过期
+	if counting {
+		if err := v.client.Delete(uuid, v.key+":attempts"); err != nil {
+			logc.Errorf(c.ctx, "删除验证次数失败: %v", err)
+		}
+		for _, id := range reserved {
+			// 验证成功后清空接收账号的失败次数，IP 的失败次数只撤销本次占用
+			if id.key == v.key+":fail" {
+				if err := v.client.Delete(id.id, id.key); err != nil {
+					logc.Errorf(c.ctx, "删除验证失败次数失败: %v", err)
+				}
+			} else {
+				v.release([]counterID{id}, c)
+			}
+		}
+	}
+	return nil
+}
+
+// discard 验证次数用完，作废验证码。验证次数保留到验证码原本的过期时间，
+// 已经读取到验证码的并发请求仍会因次数用完被拒绝
+func (v *verifier) discard(uuid string) error {
+	if err := v.client.Delete(uuid, v.key); err != nil {
+		return fmt.Errorf("作废验证码失败: %w", err)
+	}
+	return ErrTooManyAttempts
+}
+
+// reserveLockout 将接收账号和 IP 的失败次数各加一，超过阈值时撤销并返回 ErrTooManyAttempts，
+// 验证失败时保留作为失败记录，验证成功或未能比较时撤销
+func (v *verifier) reserveLockout(carrier string, c *call) ([]counterID, error) {
+	if v.options.lockoutThreshold <= 0 {
+		return nil, nil
+	}
+	var reserved []counterID
+	for _, id := range v.lockoutIDs(carrier, c) {
+		n, err := v.client.Incr(c.ctx, id.id, id.key, 1, v.options.lockoutWindow)
+		if err != nil {
+			v.release(reserved, c)
+			return nil, fmt.Errorf("记录验证失败次数失败: %w", err)
+		}
+		reserved = append(reserved, id)
+		if n > int64(v.options.lockoutThreshold) {
+			v.release(reserved, c)
+			return nil, ErrTooManyAttempts
+		}
+	}
+	return reserved, nil
+}
+
+// release 撤销占用的失败次数
+func (v *verifier) release(ids []counterID, c *call) {
+	for _, id := range ids {
+		if _, err := v.client.Incr(c.ctx, id.id, id.key, -1, v.options.lockoutWindow); err != nil {
+			logc.Errorf(c.ctx, "撤销验证失败次数失败: %v", err)
+		}
+	}
+}
+
+type counterID struct {
+	id  string
+	key string
+}
+
+// lockoutIDs 返回需要计数的接收账号和 IP
+func (v *verifier) lockoutIDs(carrier string, c *call) []counterID {
+	var ids []counterID
+	if carrier != "" {
+		ids = append(ids, counterID{carrier, v.key + ":fail"})
+	}
+	if c.ip != "" {
+		ids = append(ids, counterID{c.ip, v.key + ":fail_ip"})
+	}
+	return ids
+}
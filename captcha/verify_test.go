@@ -0,0 +1,252 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/cache"
+)
+
+// plainCache 只实现 cache.Cache 接口
+type plainCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newPlainCache() *plainCache {
+	return &plainCache{data: map[string][]byte{}}
+}
+
+func (c *plainCache) Get(key string, value any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.data[key]
+	if !ok {
+		return cache.ErrNotFound
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (c *plainCache) Set(key string, value any, expire time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = data
+	return nil
+}
+
+func (c *plainCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func newTestClient(t *testing.T) *cache.Client {
+	t.Helper()
+	memory := cache.NewMemory(nil)
+	t.Cleanup(func() { memory.Close() })
+	return cache.NewClient(memory)
+}
+
+func TestVerifyAttempts(t *testing.T) {
+	c := NewCaptcha[string](newTestClient(t), WithMaxAttempts(3))
+	uuid, code, err := c.Generate("test", "payload", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		if _, err := c.Verify("test", uuid, "wrong"); !errors.Is(err, ErrMismatch) {
+			t.Fatalf("第 %d 次错误验证应当返回 ErrMismatch，实际为 %v", i+1, err)
+		}
+	}
+	if _, err := c.Verify("test", uuid, "wrong"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("验证次数用完应当返回 ErrTooManyAttempts，实际为 %v", err)
+	}
+	if _, err := c.Verify("test", uuid, code); !errors.Is(err, ErrExpired) {
+		t.Fatalf("验证次数用完后验证码应当作废，实际为 %v", err)
+	}
+
+	uuid, code, _ = c.Generate("test", "payload", time.Minute)
+	payload, err := c.Verify("test", uuid, code)
+	if err != nil || payload != "payload" {
+		t.Fatalf("Verify = %q, %v", payload, err)
+	}
+	if _, err := c.Verify("test", uuid, code); !errors.Is(err, ErrExpired) {
+		t.Fatalf("验证成功后验证码应当作废，实际为 %v", err)
+	}
+}
+
+func TestVerifyLockout(t *testing.T) {
+	client := newTestClient(t)
+	c := NewCaptcha[string](client, WithLockout(2, time.Minute))
+	ip := WithIP("203.0.113.7")
+	for range 2 {
+		uuid, _, _ := c.Generate("test", "", time.Minute)
+		if _, err := c.Verify("test", uuid, "wrong", ip); !errors.Is(err, ErrMismatch) {
+			t.Fatal(err)
+		}
+	}
+
+	uuid, code, _ := c.Generate("test", "", time.Minute)
+	if _, err := c.Verify("test", uuid, code, ip); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("失败次数达到阈值后应当锁定，实际为 %v", err)
+	}
+	// 锁定不影响其他 IP，也不会消耗验证码
+	if _, err := c.Verify("test", uuid, code, WithIP("198.51.100.1")); err != nil {
+		t.Fatalf("其他 IP 不应被锁定: %v", err)
+	}
+}
+
+func TestVerifyWithoutStore(t *testing.T) {
+	plain := newPlainCache()
+	c := NewCaptcha[string](cache.NewClient(plain), WithLockout(3, time.Minute))
+	uuid, code, err := c.Generate("test", "payload", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := c.Verify("test", uuid, code, WithIP("203.0.113.7"))
+	if err != nil || payload != "payload" {
+		t.Fatalf("后端没有实现 Store 时验证成功不应出错: %q, %v", payload, err)
+	}
+	if len(plain.data) != 0 {
+		t.Fatalf("检查锁定时不应写入缓存: %v", plain.data)
+	}
+
+	// 无法计数时验证码错误仍然返回 ErrMismatch
+	uuid, code, _ = c.Generate("test", "payload", time.Minute)
+	for range 10 {
+		if _, err := c.Verify("test", uuid, "wrong"); !errors.Is(err, ErrMismatch) {
+			t.Fatalf("后端没有实现 Store 时验证码错误应当返回 ErrMismatch，实际为 %v", err)
+		}
+	}
+	if _, err := c.Verify("test", uuid, code); err != nil {
+		t.Fatalf("Verify = %v", err)
+	}
+}
+
+// slowStore 读取后等待一段时间再返回，放大并发请求在读取与计数之间的竞争
+type slowStore struct {
+	*cache.Memory
+}
+
+func (s slowStore) Get(key string, value any) error {
+	defer time.Sleep(20 * time.Millisecond)
+	return s.Memory.Get(key, value)
+}
+
+func (s slowStore) GetWithContext(ctx context.Context, key string, value any) error {
+	defer time.Sleep(20 * time.Millisecond)
+	return s.Memory.GetWithContext(ctx, key, value)
+}
+
+func newSlowClient(t *testing.T) *cache.Client {
+	t.Helper()
+	memory := cache.NewMemory(nil)
+	t.Cleanup(func() { memory.Close() })
+	return cache.NewClient(slowStore{memory})
+}
+
+func TestVerifyAttemptsConcurrent(t *testing.T) {
+	client := newSlowClient(t)
+	uuid, _, err := NewCaptcha[string](client).Generate("test", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compared atomic.Int32
+	v := &verifier{
+		client:  client,
+		key:     "test",
+		options: newOptions(nil, []Option{WithMaxAttempts(3)}),
+		match: func(expected string, actual string) bool {
+			compared.Add(1)
+			return false
+		},
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := v.verify(uuid, "", "wrong", false, newCall(nil))
+			if !errors.Is(err, ErrMismatch) && !errors.Is(err, ErrTooManyAttempts) && !errors.Is(err, ErrExpired) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := compared.Load(); n > 3 {
+		t.Fatalf("并发验证时比较了 %d 次验证码，不应超过验证次数 3", n)
+	}
+}
+
+func TestVerifyLockoutConcurrent(t *testing.T) {
+	c := NewCaptcha[string](newSlowClient(t), WithLockout(3, time.Minute))
+	var uuids []string
+	for range 20 {
+		uuid, _, err := c.Generate("test", "", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uuids = append(uuids, uuid)
+	}
+
+	var (
+		wg         sync.WaitGroup
+		mismatches atomic.Int32
+	)
+	for _, uuid := range uuids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Verify("test", uuid, "wrong", WithIP("203.0.113.7"))
+			switch {
+			case errors.Is(err, ErrMismatch):
+				mismatches.Add(1)
+			case !errors.Is(err, ErrTooManyAttempts):
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := mismatches.Load(); n != 3 {
+		t.Fatalf("并发验证时同一 IP 比较了 %d 次，应当只有锁定阈值 3 次", n)
+	}
+}
+
+// failingDelete 删除总是失败
+type failingDelete struct {
+	*cache.Memory
+}
+
+var errDelete = errors.New("删除失败")
+
+func (failingDelete) Delete(key string) error {
+	return errDelete
+}
+
+func TestVerifyDiscardError(t *testing.T) {
+	memory := cache.NewMemory(nil)
+	t.Cleanup(func() { memory.Close() })
+	c := NewCaptcha[string](cache.NewClient(failingDelete{memory}), WithMaxAttempts(1))
+	uuid, _, err := c.Generate("test", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Verify("test", uuid, "wrong"); !errors.Is(err, errDelete) {
+		t.Fatalf("作废验证码失败时应当返回错误，实际为 %v", err)
+	}
+	// 验证码没能删除，次数已经用完仍然拒绝
+	if _, err := c.Verify("test", uuid, "wrong"); !errors.Is(err, errDelete) {
+		t.Fatalf("次数用完后应当再次尝试作废验证码，实际为 %v", err)
+	}
+}
@@ -1,6 +1,17 @@
 package config
 
-import "sync"
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"sigs.k8s.io/yaml"
+)
 
 var instances sync.Map
 
@@ -14,4 +25,78 @@ func load[T any](path string, fn func(string) (*T, error)) (*T, error) {
 	}
 	instances.Store(path, cfg)
 	return cfg, nil
-}
\ No newline at end of file
+}
+
+// parse 读取并解析配置文件，解析后为仍为零值的字段填充 default 标签的默认值，再按 validate 标签校验
+func parse[T any](path string, format string) (*T, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	return decodeFormat[T](path, format, file)
+}
+
+// decode 按文件扩展名解析配置内容：.json、.toml、.yaml、.yml
+func decode[T any](path string, data []byte) (*T, error) {
+	format, err := formatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeFormat[T](path, format, data)
+}
+
+func decodeFormat[T any](path string, format string, data []byte) (*T, error) {
+	var cfg T
+	root := reflect.ValueOf(&cfg).Elem()
+	var list []*field
+	if root.Kind() == reflect.Struct {
+		list = fields(root.Type())
+	}
+	if err := unmarshalFormat(format, data, &cfg); err != nil {
+		return nil, err
+	}
+	if err := finish(root, list, path, nil); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func formatOf(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return "json", nil
+	case ".toml":
+		return "toml", nil
+	case ".yaml", ".yml":
+		return "yaml", nil
+	default:
+		return "", fmt.Errorf("不支持的配置文件格式: %s", ext)
+	}
+}
+
+// unmarshal 按文件扩展名将配置内容解析到 v，v 中已有的值在配置中没有出现时保持不变
+func unmarshal(path string, data []byte, v any) error {
+	format, err := formatOf(path)
+	if err != nil {
+		return err
+	}
+	return unmarshalFormat(format, data, v)
+}
+
+func unmarshalFormat(format string, data []byte, v any) error {
+	var err error
+	switch format {
+	case "json":
+		err = json.Unmarshal(data, v)
+	case "toml":
+		err = toml.Unmarshal(data, v)
+	case "yaml":
+		err = yaml.Unmarshal(data, v)
+	default:
+		return fmt.Errorf("不支持的配置文件格式: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("解析%s类型配置文件失败: %w", format, err)
+	}
+	return nil
+}
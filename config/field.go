@@ -0,0 +1,311 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// field 配置结构体中的一个叶子字段（非结构体字段）
+type field struct {
+	index []int
+	path  []string   // 各级配置名，取 json 标签，其次 toml 标签，都没有时为字段名的蛇形写法
+	names [][]string // 各级在配置文件中可能使用的键名，匹配时不区分大小写
+	env   string     // env 标签指定的环境变量名，"-" 表示不读取环境变量
+	flag  string     // flag 标签指定的参数名，"-" 表示不读取命令行参数
+	def   *string    // default 标签的默认值，没有标签时为空
+	rules string     // validate 标签的校验规则
+	typ   reflect.Type
+}
+
+// key 字段的配置路径，例如 redis.addr
+func (f *field) key() string {
+	return strings.Join(f.path, ".")
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	durationType        = reflect.TypeFor[time.Duration]()
+)
+
+// fields 列出 t 的所有叶子字段，嵌套结构体（包括结构体指针）会展开，匿名结构体字段不增加层级
+func fields(t reflect.Type) []*field {
+	var list []*field
+	walkFields(t, nil, nil, nil, map[reflect.Type]bool{}, &list)
+	return list
+}
+
+func walkFields(t reflect.Type, index []int, path []string, names [][]string, visiting map[reflect.Type]bool, list *[]*field) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, ok := configName(sf)
+		if !ok {
+			continue
+		}
+		fi := append(append([]int{}, index...), i)
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer && ft.Elem().Kind() == reflect.Struct {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !isLeaf(ft) {
+			if sf.Anonymous && !hasTag(sf) {
+				walkFields(ft, fi, path, names, visiting, list)
+			} else {
+				walkFields(ft, fi, append(clone(path), name), append(clone(names), keyNames(sf, name)), visiting, list)
+			}
+			continue
+		}
+		f := &field{
+			index: fi,
+			path:  append(clone(path), name),
+			names: append(clone(names), keyNames(sf, name)),
+			env:   sf.Tag.Get("env"),
+			flag:  sf.Tag.Get("flag"),
+			rules: sf.Tag.Get("validate"),
+			typ:   sf.Type,
+		}
+		if def, ok := sf.Tag.Lookup("default"); ok {
+			f.def = &def
+		}
+		*list = append(*list, f)
+	}
+}
+
+func clone[S ~[]E, E any](s S) S {
+	return append(S(nil), s...)
+}
+
+// isLeaf 实现了 TextUnmarshaler 的结构体（例如 time.Time）作为整体赋值，不再展开
+func isLeaf(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func hasTag(sf reflect.StructField) bool {
+	return sf.Tag.Get("json") != "" || sf.Tag.Get("toml") != "" || sf.Tag.Get("yaml") != ""
+}
+
+// configName 字段的配置名，标签为 "-" 时忽略该字段
+func configName(sf reflect.StructField) (string, bool) {
+	for _, tag := range []string{"json", "toml"} {
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name == "-" {
+			return "", false
+		}
+		if name != "" {
+			return name, true
+		}
+	}
+	return snakeCase(sf.Name), true
+}
+
+func keyNames(sf reflect.StructField, name string) []string {
+	names := []string{name, sf.Name}
+	if toml, _, _ := strings.Cut(sf.Tag.Get("toml"), ","); toml != "" {
+		names = append(names, toml)
+	}
+	return names
+}
+
+// snakeCase MaxAge 转换为 max_age，连续的大写字母视为一个单词，例如 HTTPPort 转换为 http_port
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// envName 自动映射的环境变量名，例如前缀为 APP 时 redis.addr 对应 APP_REDIS_ADDR
+func envName(prefix string, path []string) string {
+	name := strings.ToUpper(strings.Join(path, "_"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+	if prefix == "" {
+		return name
+	}
+	return strings.TrimSuffix(prefix, "_") + "_" + name
+}
+
+// settable 返回字段的值，途经的空结构体指针会被创建
+func settable(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// value 返回字段的值，途经空结构体指针时返回 false
+func value(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// lookup 在解析后的配置文件中查找字段，键名不区分大小写，以 + 结尾的追加写法也视为该字段
+func lookup(m map[string]any, names [][]string) (any, bool) {
+	var cur any = m
+	for _, candidates := range names {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		found := false
+		for k, v := range obj {
+			if matchKey(strings.TrimSuffix(k, "+"), candidates) {
+				cur, found = v, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// setString 将环境变量或命令行参数的文本赋值给字段，切片按逗号分隔
+func setString(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setString(v.Elem(), s)
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		var parts []string
+		if s != "" {
+			parts = strings.Split(s, ",")
+		}
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setString(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("不支持的字段类型 %s", v.Type())
+	}
+	return nil
+}
+
+// deepCopy 复制默认值，避免加载时修改调用方的切片和映射
+func deepCopy(dst reflect.Value, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		deepCopy(p.Elem(), src.Elem())
+		dst.Set(p)
+	case reflect.Struct:
+		// 先整体复制，未导出字段无法逐个复制
+		dst.Set(src)
+		for i := range src.NumField() {
+			if dst.Field(i).CanSet() {
+				deepCopy(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := range src.Len() {
+			deepCopy(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			v := reflect.New(src.Type().Elem()).Elem()
+			deepCopy(v, iter.Value())
+			m.SetMapIndex(iter.Key(), v)
+		}
+		dst.Set(m)
+	default:
+		dst.Set(src)
+	}
+}
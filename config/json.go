@@ -1,23 +1,10 @@
 package config
 
-import (
-	"encoding/json"
-	"fmt"
-	"os"
-)
-
+// NewJSON 解析json配置文件，填充 default 标签的默认值并按 validate 标签校验
 func NewJSON[T any](path string) (*T, error) {
-	file, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %w", err)
-	}
-	var cfg T
-	if err := json.Unmarshal(file, &cfg); err != nil {
-		return nil, fmt.Errorf("解析json类型配置文件失败: %w", err)
-	}
-	return &cfg, nil
+	return parse[T](path, "json")
 }
 
 func LoadJSON[T any](path string) (*T, error) {
 	return load(path, NewJSON[T])
-}
\ No newline at end of file
+}
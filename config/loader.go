@@ -0,0 +1,239 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// 配置来源
+const (
+	LayerDefault = "default" // 默认值
+	LayerFile    = "file"    // 配置文件
+	LayerEnv     = "env"     // 环境变量
+	LayerFlag    = "flag"    // 命令行参数
+)
+
+// Source 字段的最终取值来源
+type Source struct {
+	Layer string // default、file、env、flag
+	Name  string // 配置文件路径、环境变量名或命令行参数名，默认值时为空
+}
+
+func (s Source) String() string {
+	if s.Name == "" {
+		return s.Layer
+	}
+	return s.Layer + ":" + s.Name
+}
+
+// Provenance 记录每个字段的取值来源，键为字段的配置路径，例如 redis.addr
+type Provenance map[string]Source
+
+// Loader 分层加载配置，后面的层覆盖前面的层：默认值、配置文件、环境变量、命令行参数。
+//
+// 环境变量：字段的 env 标签指定变量名，例如 `env:"REDIS_ADDR"`；设置了 EnvPrefix 时，
+// 没有 env 标签的字段按配置路径自动映射，例如前缀为 APP 时 redis.addr 对应 APP_REDIS_ADDR。
+// 命令行参数：按配置路径命名，例如 -redis.addr=127.0.0.1:6379，也可以通过 flag 标签指定。
+// 配置路径取 json 标签，其次 toml 标签，都没有时为字段名的蛇形写法。
+//
+// 配置文件可以通过 include 引用其他文件，多个文件按 merge 的规则深度合并，可以混合使用不同格式；
+// 设置了环境名时还会合并环境配置文件，例如 config.yaml 在 prod 环境下合并 config.prod.yaml。
+//
+// 全部加载完成后仍为零值的字段使用 default 标签的默认值，包括配置文件、环境变量等创建的结构体指针中的字段；
+// 之后按 validate 标签校验，所有校验失败的字段汇总为一个 *ValidationError 返回
+type Loader[T any] struct {
+	Defaults   *T                              // 默认值，为空时使用零值
+	Path       string                          // 配置文件，格式由扩展名决定：.json、.toml、.yaml、.yml，为空时不读取
+	Profile    string                          // 环境名，例如 dev、prod，为空时读取 ProfileEnv 指定的环境变量
+	ProfileEnv string                          // 保存环境名的环境变量，默认 APP_ENV
+	EnvPrefix  string                          // 自动映射环境变量的前缀，为空时只读取 env 标签
+	Args       []string                        // 命令行参数，通常为 os.Args[1:]，为空时不解析，与配置字段无关的参数会被忽略
+	LookupEnv  func(key string) (string, bool) // 读取环境变量，默认 os.LookupEnv
+}
+
+// Load 按层加载配置，返回配置和每个字段的来源
+func (l *Loader[T]) Load() (*T, Provenance, error) {
+	var cfg T
+	if l.Defaults != nil {
+		deepCopy(reflect.ValueOf(&cfg).Elem(), reflect.ValueOf(l.Defaults).Elem())
+	}
+	root := reflect.ValueOf(&cfg).Elem()
+	if root.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("配置类型必须是结构体: %s", root.Type())
+	}
+	list := fields(root.Type())
+	provenance := make(Provenance, len(list))
+	for _, f := range list {
+		provenance[f.key()] = Source{Layer: LayerDefault}
+	}
+	if l.Path != "" {
+		if err := l.loadFile(&cfg, list, provenance); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := l.loadEnv(root, list, provenance); err != nil {
+		return nil, nil, err
+	}
+	if len(l.Args) > 0 {
+		if err := l.loadFlags(root, list, provenance); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := finish(root, list, l.Path, provenance); err != nil {
+		return nil, nil, err
+	}
+	return &cfg, provenance, nil
+}
+
+func (l *Loader[T]) loadFile(cfg *T, list []*field, provenance Provenance) error {
+	layers, err := readLayers(l.Path, map[string]bool{})
+	if err != nil {
+		return err
+	}
+	if profile := l.profile(); profile != "" {
+		path, err := profilePath(l.Path, profile)
+		if err != nil {
+			return err
+		}
+		if path != "" {
+			overlay, err := readLayers(path, map[string]bool{})
+			if err != nil {
+				return err
+			}
+			layers = append(layers, overlay...)
+		}
+	}
+
+	merged := map[string]any{}
+	for _, layer := range layers {
+		merge(merged, layer.data)
+		for _, f := range list {
+			v, ok := lookup(layer.data, f.names)
+			if !ok {
+				continue
+			}
+			if v == nil {
+				provenance[f.key()] = Source{Layer: LayerDefault}
+			} else {
+				provenance[f.key()] = Source{Layer: LayerFile, Name: layer.path}
+			}
+		}
+	}
+
+	// 合并后统一转换为 json 解析，不同格式的文件可以混合使用
+	normalize(merged, reflect.TypeFor[T]())
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("合并配置文件失败: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// profile 返回环境名
+func (l *Loader[T]) profile() string {
+	if l.Profile != "" {
+		return l.Profile
+	}
+	name := l.ProfileEnv
+	if name == "" {
+		name = "APP_ENV"
+	}
+	profile, _ := l.lookupEnv(name)
+	return profile
+}
+
+func (l *Loader[T]) lookupEnv(key string) (string, bool) {
+	if l.LookupEnv != nil {
+		return l.LookupEnv(key)
+	}
+	return os.LookupEnv(key)
+}
+
+func (l *Loader[T]) loadEnv(root reflect.Value, list []*field, provenance Provenance) error {
+	for _, f := range list {
+		name := f.env
+		if name == "-" || name == "" && l.EnvPrefix == "" {
+			continue
+		}
+		if name == "" {
+			name = envName(l.EnvPrefix, f.path)
+		}
+		s, ok := l.lookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setString(settable(root, f.index), s); err != nil {
+			return fmt.Errorf("环境变量 %s 的值无效: %w", name, err)
+		}
+		provenance[f.key()] = Source{Layer: LayerEnv, Name: name}
+	}
+	return nil
+}
+
+func (l *Loader[T]) loadFlags(root reflect.Value, list []*field, provenance Provenance) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	for _, f := range list {
+		name := f.flag
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.key()
+		}
+		set := func(s string) error {
+			if err := setString(settable(root, f.index), s); err != nil {
+				return err
+			}
+			provenance[f.key()] = Source{Layer: LayerFlag, Name: name}
+			return nil
+		}
+		usage := fmt.Sprintf("%s (%s)", f.key(), f.typ)
+		if f.typ.Kind() == reflect.Bool {
+			fs.BoolFunc(name, usage, set)
+		} else {
+			fs.Func(name, usage, set)
+		}
+	}
+	// 命令行中还有应用自己的参数，例如 -v，只解析配置字段对应的参数
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(knownArgs(fs, l.Args)); err != nil {
+		return fmt.Errorf("解析命令行参数失败: %w", err)
+	}
+	return nil
+}
+
+// knownArgs 从 args 中挑出 fs 中定义过的参数及其取值，忽略其他参数和位置参数，遇到 -- 时停止
+func knownArgs(fs *flag.FlagSet, args []string) []string {
+	var known []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		known = append(known, arg)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); hasValue || ok && b.IsBoolFlag() {
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			known = append(known, args[i])
+		}
+	}
+	return known
+}
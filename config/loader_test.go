@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testRedis struct {
+	Addr    string        `json:"addr"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+type testConfig struct {
+	Name  string    `json:"name"`
+	Port  int       `json:"port" default:"8080"`
+	Debug bool      `json:"debug"`
+	Tags  []string  `json:"tags"`
+	Redis testRedis `json:"redis"`
+}
+
+type testPool struct {
+	Addr string `json:"addr"`
+	Mode string `json:"mode" default:"single" validate:"in=single,cluster:不支持的模式"`
+}
+
+// testPoolConfig 的 Pool 默认为空指针，由配置文件、环境变量或命令行参数创建
+type testPoolConfig struct {
+	Pool *testPool `json:"pool"`
+}
+
+func TestDefaultsInPointerStruct(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.json":  `{"pool": {"addr": "127.0.0.1:6379"}}`,
+		"invalid.json": `{"pool": {"mode": "sentinel"}}`,
+		"empty.json":   `{}`,
+	})
+	check := func(t *testing.T, cfg *testPoolConfig, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Pool == nil || cfg.Pool.Mode != "single" {
+			t.Fatalf("新创建的结构体指针应当填充默认值: %+v", cfg.Pool)
+		}
+	}
+
+	t.Run("NewJSON", func(t *testing.T) {
+		cfg, err := NewJSON[testPoolConfig](filepath.Join(dir, "config.json"))
+		check(t, cfg, err)
+		var verr *ValidationError
+		if _, err := NewJSON[testPoolConfig](filepath.Join(dir, "invalid.json")); !errors.As(err, &verr) {
+			t.Fatalf("配置文件中的无效值应当校验失败，实际为 %v", err)
+		}
+		if cfg, err := NewJSON[testPoolConfig](filepath.Join(dir, "empty.json")); err != nil || cfg.Pool != nil {
+			t.Fatalf("未配置的结构体指针应当保持为空: %+v, %v", cfg.Pool, err)
+		}
+	})
+	t.Run("Loader", func(t *testing.T) {
+		l := &Loader[testPoolConfig]{Path: filepath.Join(dir, "config.json"), LookupEnv: noEnv}
+		cfg, provenance, err := l.Load()
+		check(t, cfg, err)
+		if got := provenance["pool.mode"]; got.Layer != LayerDefault {
+			t.Fatalf("pool.mode 的来源为 %v", got)
+		}
+	})
+	t.Run("env", func(t *testing.T) {
+		l := &Loader[testPoolConfig]{
+			EnvPrefix: "APP",
+			LookupEnv: func(key string) (string, bool) {
+				return "127.0.0.1:6379", key == "APP_POOL_ADDR"
+			},
+		}
+		cfg, _, err := l.Load()
+		check(t, cfg, err)
+	})
+	t.Run("flags", func(t *testing.T) {
+		l := &Loader[testPoolConfig]{Args: []string{"-pool.mode=cluster"}, LookupEnv: noEnv}
+		cfg, _, err := l.Load()
+		if err != nil || cfg.Pool == nil || cfg.Pool.Mode != "cluster" {
+			t.Fatalf("命令行参数不应被默认值覆盖: %+v, %v", cfg, err)
+		}
+	})
+}
+
+func TestLoaderFlagsIgnoreUnknown(t *testing.T) {
+	l := &Loader[testConfig]{
+		Args:      []string{"-v", "serve", "--port", "9000", "-verbose=true", "-debug", "-redis.addr=127.0.0.1:6379", "--", "-name=ignored"},
+		LookupEnv: func(string) (string, bool) { return "", false },
+	}
+	cfg, provenance, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 9000 || !cfg.Debug || cfg.Redis.Addr != "127.0.0.1:6379" || cfg.Name != "" {
+		t.Fatalf("解析结果不正确: %+v", cfg)
+	}
+	if got := provenance["port"]; got != (Source{Layer: LayerFlag, Name: "port"}) {
+		t.Fatalf("port 的来源为 %v", got)
+	}
+}
+
+func TestLoaderFlagsInvalidValue(t *testing.T) {
+	l := &Loader[testConfig]{
+		Args:      []string{"-port=abc"},
+		LookupEnv: func(string) (string, bool) { return "", false },
+	}
+	if _, _, err := l.Load(); err == nil {
+		t.Fatal("参数值无效时应当返回错误")
+	}
+}
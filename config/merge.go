@@ -0,0 +1,241 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// includeKey 配置文件中引用其他文件的键，值为文件路径或路径列表，相对路径相对于当前文件所在目录
+const includeKey = "include"
+
+// layer 一个配置文件解析后的内容，不包含 include 引用的文件
+type layer struct {
+	path string
+	data map[string]any
+}
+
+// readLayers 读取配置文件及其 include 引用的文件，按合并顺序返回：被引用的文件在前，当前文件在后
+func readLayers(path string, visiting map[string]bool) ([]layer, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("获取配置文件路径失败: %w", err)
+	}
+	if visiting[abs] {
+		return nil, fmt.Errorf("配置文件循环引用: %s", path)
+	}
+	visiting[abs] = true
+	defer delete(visiting, abs)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	m, err := decodeMap(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var layers []layer
+	for k, v := range m {
+		if !strings.EqualFold(k, includeKey) {
+			continue
+		}
+		delete(m, k)
+		includes, err := includePaths(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, include := range includes {
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(path), include)
+			}
+			sub, err := readLayers(include, visiting)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, sub...)
+		}
+	}
+	return append(layers, layer{path: path, data: m}), nil
+}
+
+func includePaths(v any) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		paths := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("include 必须是文件路径或路径列表")
+			}
+			paths = append(paths, s)
+		}
+		return paths, nil
+	}
+	return nil, fmt.Errorf("include 必须是文件路径或路径列表")
+}
+
+// decodeMap 将配置内容解析为 map，json 中的数字保留为 json.Number，避免大整数丢失精度
+func decodeMap(path string, data []byte) (map[string]any, error) {
+	format, err := formatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]any{}
+	if format == "json" {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&m); err != nil {
+			return nil, fmt.Errorf("解析json类型配置文件失败: %w", err)
+		}
+		return m, nil
+	}
+	if err := unmarshalFormat(format, data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// profilePath 查找环境配置文件，例如 config.yaml 在 prod 环境下对应 config.prod.yaml，
+// 优先使用与基础配置相同的格式，其次依次尝试 .json、.toml、.yaml、.yml，不存在时返回空
+func profilePath(path string, profile string) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext) + "." + profile
+	for _, e := range []string{ext, ".json", ".toml", ".yaml", ".yml"} {
+		candidate := base + e
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		} else if !os.IsNotExist(err) {
+			return "", fmt.Errorf("读取配置文件失败: %w", err)
+		}
+	}
+	return "", nil
+}
+
+// merge 将 src 深度合并到 dst，键名不区分大小写：
+// 两边都是 map 时递归合并；值为 null 时删除该键，恢复为默认值；
+// 键名以 + 结尾且两边都是列表时追加到原列表后面，例如 tags+: [c]；其他情况 src 覆盖 dst
+func merge(dst map[string]any, src map[string]any) {
+	for k, v := range src {
+		name, appendList := strings.CutSuffix(k, "+")
+		existing := ""
+		for dk := range dst {
+			if strings.EqualFold(dk, name) {
+				existing = dk
+				break
+			}
+		}
+		if existing == "" {
+			if v != nil {
+				dst[name] = v
+			}
+			continue
+		}
+
+		if v == nil {
+			delete(dst, existing)
+			continue
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if m, ok := dst[existing].(map[string]any); ok {
+				merge(m, v)
+				continue
+			}
+		case []any:
+			if list, ok := dst[existing].([]any); ok && appendList {
+				dst[existing] = append(append([]any{}, list...), v...)
+				continue
+			}
+		}
+		dst[existing] = v
+	}
+}
+
+// normalize 将 map 的键名改为 json 解析时使用的名称，使 toml 标签、字段名等写法都能被识别，
+// 并将 time.Duration 字段的字符串（例如 "5s"）转换为纳秒数，
+// 混合使用多种格式的配置文件时仍然可以统一通过 json 解析
+func normalize(v any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == durationType {
+		if s, ok := v.(string); ok {
+			if d, err := time.ParseDuration(s); err == nil {
+				return int64(d)
+			}
+		}
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			if !isLeaf(t) {
+				normalizeStruct(v, t)
+			}
+		case reflect.Map:
+			for k, item := range v {
+				v[k] = normalize(item, t.Elem())
+			}
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, item := range v {
+				v[i] = normalize(item, t.Elem())
+			}
+		}
+	}
+	return v
+}
+
+func normalizeStruct(m map[string]any, t reflect.Type) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, ok := configName(sf)
+		if !ok {
+			continue
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && !hasTag(sf) && ft.Kind() == reflect.Struct {
+			normalizeStruct(m, ft)
+			continue
+		}
+
+		jsonName := sf.Name
+		if tag, _, _ := strings.Cut(sf.Tag.Get("json"), ","); tag != "" {
+			jsonName = tag
+		}
+		for k, v := range m {
+			if !matchKey(k, keyNames(sf, name)) {
+				continue
+			}
+			delete(m, k)
+			m[jsonName] = normalize(v, sf.Type)
+			break
+		}
+	}
+}
+
+func matchKey(k string, names []string) bool {
+	for _, name := range names {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles 在临时目录中写入配置文件，返回目录
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func noEnv(string) (string, bool) {
+	return "", false
+}
+
+func TestLoaderMergeTOMLProfile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.toml": `
+name = "app"
+tags = ["a", "b"]
+
+[redis]
+addr = "127.0.0.1:6379"
+timeout = "5s"
+`,
+		"config.prod.toml": `
+"tags+" = ["c"]
+
+[redis]
+timeout = "10s"
+`,
+	})
+	base := filepath.Join(dir, "config.toml")
+
+	l := &Loader[testConfig]{Path: base, LookupEnv: noEnv}
+	cfg, _, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Redis.Timeout != 5*time.Second {
+		t.Fatalf("Timeout = %v", cfg.Redis.Timeout)
+	}
+
+	l.Profile = "prod"
+	cfg, provenance, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Redis.Timeout != 10*time.Second || cfg.Redis.Addr != "127.0.0.1:6379" || cfg.Name != "app" {
+		t.Fatalf("合并结果不正确: %+v", cfg)
+	}
+	if !slices.Equal(cfg.Tags, []string{"a", "b", "c"}) {
+		t.Fatalf("tags+ 应当追加到列表后面，实际为 %v", cfg.Tags)
+	}
+	if got := provenance["redis.timeout"]; got.Layer != LayerFile || !strings.HasSuffix(got.Name, "config.prod.toml") {
+		t.Fatalf("redis.timeout 的来源为 %v", got)
+	}
+	if got := provenance["redis.addr"]; got.Name != base {
+		t.Fatalf("redis.addr 的来源为 %v", got)
+	}
+}
+
+func TestLoaderMergeNullDeletes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml":      "port: 9000\ntags: [a, b]\nredis:\n  timeout: 1m\n",
+		"config.prod.json": `{"port": null, "tags": ["x"], "Redis": {"Timeout": "2s"}}`,
+	})
+	cfg, provenance, err := (&Loader[testConfig]{Path: filepath.Join(dir, "config.yaml"), Profile: "prod", LookupEnv: noEnv}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 8080 || provenance["port"].Layer != LayerDefault {
+		t.Fatalf("null 应当删除该键并恢复默认值，实际为 %d，来源 %v", cfg.Port, provenance["port"])
+	}
+	if !slices.Equal(cfg.Tags, []string{"x"}) {
+		t.Fatalf("没有 + 后缀时列表应当被替换，实际为 %v", cfg.Tags)
+	}
+	if cfg.Redis.Timeout != 2*time.Second {
+		t.Fatalf("键名不区分大小写，Timeout = %v", cfg.Redis.Timeout)
+	}
+}
+
+func TestLoaderInclude(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.json":   `{"name": "base", "port": 7000}`,
+		"config.yaml": "include: base.json\nport: 7001\n",
+		"a.json":      `{"include": "b.json"}`,
+		"b.json":      `{"include": "a.json"}`,
+	})
+	cfg, provenance, err := (&Loader[testConfig]{Path: filepath.Join(dir, "config.yaml"), LookupEnv: noEnv}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "base" || cfg.Port != 7001 {
+		t.Fatalf("被引用的文件应当先合并: %+v", cfg)
+	}
+	if !strings.HasSuffix(provenance["name"].Name, "base.json") {
+		t.Fatalf("name 的来源为 %v", provenance["name"])
+	}
+
+	if _, _, err := (&Loader[testConfig]{Path: filepath.Join(dir, "a.json"), LookupEnv: noEnv}).Load(); err == nil || !strings.Contains(err.Error(), "循环引用") {
+		t.Fatalf("循环引用应当返回错误，实际为 %v", err)
+	}
+}
@@ -1,24 +1,10 @@
 package config
 
-import (
-	"fmt"
-	"os"
-
-	"github.com/BurntSushi/toml"
-)
-
+// NewTOML 解析toml配置文件，填充 default 标签的默认值并按 validate 标签校验
 func NewTOML[T any](path string) (*T, error) {
-	file, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %w", err)
-	}
-	var cfg T
-	if err := toml.Unmarshal(file, &cfg); err != nil {
-		return nil, fmt.Errorf("解析toml类型配置文件失败: %w", err)
-	}
-	return &cfg, nil
+	return parse[T](path, "toml")
 }
 
 func LoadTOML[T any](path string) (*T, error) {
 	return load(path, NewTOML[T])
-}
\ No newline at end of file
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ligaolin/goweb/v2/validator"
+)
+
+// FieldError 字段校验失败
+type FieldError struct {
+	Key    string // 字段的配置路径，例如 redis.addr
+	Source Source // 字段的取值来源，不分层加载时为空
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	if e.Source.Layer == "" {
+		return fmt.Sprintf("%s: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("%s（来自 %s）: %v", e.Key, e.Source, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError 汇总所有校验失败的字段
+type ValidationError struct {
+	Path   string // 配置文件路径
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.Path == "" {
+		b.WriteString("配置校验失败:")
+	} else {
+		fmt.Fprintf(&b, "配置文件 %s 校验失败:", e.Path)
+	}
+	for _, f := range e.Fields {
+		b.WriteString("\n  ")
+		b.WriteString(f.Error())
+	}
+	return b.String()
+}
+
+// finish 所有层加载完成后填充默认值并校验，配置文件、环境变量等创建的结构体指针中的字段同样会填充
+func finish(root reflect.Value, list []*field, path string, provenance Provenance) error {
+	if err := applyDefaults(root, list, provenance); err != nil {
+		return err
+	}
+	return validateFields(root, list, path, provenance)
+}
+
+// applyDefaults 为仍为零值的字段填充 default 标签的默认值并记录来源，位于空结构体指针中的字段不会填充
+func applyDefaults(root reflect.Value, list []*field, provenance Provenance) error {
+	for _, f := range list {
+		if f.def == nil {
+			continue
+		}
+		v, ok := value(root, f.index)
+		if !ok || !v.IsZero() {
+			continue
+		}
+		if err := setString(v, *f.def); err != nil {
+			return fmt.Errorf("字段 %s 的默认值无效: %w", f.key(), err)
+		}
+		if provenance != nil {
+			provenance[f.key()] = Source{Layer: LayerDefault}
+		}
+	}
+	return nil
+}
+
+// validateFields 按 validate 标签校验所有字段，位于空结构体指针中的字段视为未启用，不校验
+func validateFields(root reflect.Value, list []*field, path string, provenance Provenance) error {
+	var fieldErrors []*FieldError
+	for _, f := range list {
+		if f.rules == "" {
+			continue
+		}
+		v, ok := value(root, f.index)
+		if !ok {
+			continue
+		}
+		if err := validator.Value(v.Interface(), f.rules); err != nil {
+			fieldErrors = append(fieldErrors, &FieldError{Key: f.key(), Source: provenance[f.key()], Err: err})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return &ValidationError{Path: path, Fields: fieldErrors}
+	}
+	return nil
+}
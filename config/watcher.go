@@ -0,0 +1,258 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// Watcher 监听配置文件，文件变化时重新解析、校验并原子替换配置，再通知订阅者。
+// 解析或校验失败时保留上一次有效的配置
+type Watcher[T any] struct {
+	Path string
+
+	options     *watchOptions[T]
+	current     atomic.Pointer[T]
+	data        []byte     // 当前配置对应的文件内容，用于跳过内容未变化的事件
+	reloading   sync.Mutex // 串行执行重新加载，保证订阅者按顺序收到变化
+	mu          sync.Mutex
+	subscribers map[int]func(old, new *T)
+	nextID      int
+	stop        chan struct{}
+	done        chan struct{}
+	once        sync.Once
+}
+
+type watchOptions[T any] struct {
+	validate func(*T) error
+	onError  func(error)
+	interval time.Duration
+	debounce time.Duration
+	polling  bool
+}
+
+type WatchOption[T any] func(*watchOptions[T])
+
+// WithValidate 校验新配置，返回错误时拒绝本次修改
+func WithValidate[T any](fn func(*T) error) WatchOption[T] {
+	return func(o *watchOptions[T]) {
+		o.validate = fn
+	}
+}
+
+// WithErrorHandler 重新加载失败时的回调，默认记录日志
+func WithErrorHandler[T any](fn func(error)) WatchOption[T] {
+	return func(o *watchOptions[T]) {
+		o.onError = fn
+	}
+}
+
+// WithPolling 不使用文件系统通知，按 interval 轮询文件，默认 2 秒；
+// 文件系统通知不可用时（例如部分网络文件系统）会自动改为轮询
+func WithPolling[T any](interval time.Duration) WatchOption[T] {
+	return func(o *watchOptions[T]) {
+		o.polling = true
+		if interval > 0 {
+			o.interval = interval
+		}
+	}
+}
+
+// WithDebounce 合并 d 时间内的多次文件事件，默认 100 毫秒，编辑器保存文件时通常会连续触发多个事件
+func WithDebounce[T any](d time.Duration) WatchOption[T] {
+	return func(o *watchOptions[T]) {
+		o.debounce = d
+	}
+}
+
+// NewWatcher 加载配置文件并开始监听，格式由扩展名决定：.json、.toml、.yaml、.yml。
+// 与 NewJSON 等相同，每次加载都填充 default 标签的默认值并按 validate 标签校验，首次加载失败时返回错误
+func NewWatcher[T any](path string, opts ...WatchOption[T]) (*Watcher[T], error) {
+	o := &watchOptions[T]{
+		interval: 2 * time.Second,
+		debounce: 100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.onError == nil {
+		o.onError = func(err error) {
+			logc.Errorf(context.Background(), "重新加载配置文件失败: %v", err)
+		}
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("获取配置文件路径失败: %w", err)
+	}
+	w := &Watcher[T]{
+		Path:        path,
+		options:     o,
+		subscribers: make(map[int]func(old, new *T)),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if _, err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	if !o.polling {
+		fw, err := fsnotify.NewWatcher()
+		if err == nil {
+			// 监听所在目录而不是文件本身，编辑器替换文件、Kubernetes ConfigMap 切换软链接时仍然有效
+			if err = fw.Add(filepath.Dir(path)); err == nil {
+				go w.watch(fw)
+				return w, nil
+			}
+			fw.Close()
+		}
+		logc.Infof(context.Background(), "文件监听不可用，改为轮询配置文件: %v", err)
+	}
+	go w.poll()
+	return w, nil
+}
+
+// Get 返回当前配置，返回的配置不应被修改
+func (w *Watcher[T]) Get() *T {
+	return w.current.Load()
+}
+
+// Subscribe 订阅配置变化，fn 在触发重新加载的协程中按订阅顺序调用，不能在 fn 中调用 Reload，返回取消订阅的函数
+func (w *Watcher[T]) Subscribe(fn func(old, new *T)) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := w.nextID
+	w.nextID++
+	w.subscribers[id] = fn
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subscribers, id)
+	}
+}
+
+// Reload 立即重新加载配置文件，内容未变化时不通知订阅者
+func (w *Watcher[T]) Reload() error {
+	_, err := w.reload()
+	return err
+}
+
+// Close 停止监听
+func (w *Watcher[T]) Close() error {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+	return nil
+}
+
+// reload 读取并解析配置文件，校验通过后替换当前配置，返回配置是否发生变化
+func (w *Watcher[T]) reload() (bool, error) {
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		return false, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	if w.current.Load() != nil && bytes.Equal(data, w.data) {
+		return false, nil
+	}
+	cfg, err := decode[T](w.Path, data)
+	if err != nil {
+		return false, err
+	}
+	if w.options.validate != nil {
+		if err := w.options.validate(cfg); err != nil {
+			return false, fmt.Errorf("配置校验失败: %w", err)
+		}
+	}
+
+	old := w.current.Swap(cfg)
+	w.data = data
+	if old == nil {
+		return true, nil
+	}
+	w.mu.Lock()
+	subscribers := make([]func(old, new *T), 0, len(w.subscribers))
+	for id := range w.nextID {
+		if fn, ok := w.subscribers[id]; ok {
+			subscribers = append(subscribers, fn)
+		}
+	}
+	w.mu.Unlock()
+	for _, fn := range subscribers {
+		fn(old, cfg)
+	}
+	return true, nil
+}
+
+func (w *Watcher[T]) watch(fw *fsnotify.Watcher) {
+	defer close(w.done)
+	defer fw.Close()
+
+	timer := time.NewTimer(0)
+	<-timer.C
+	for {
+		select {
+		case <-w.stop:
+			timer.Stop()
+			return
+		case event, ok := <-fw.Events:
+			if !ok {
+				return
+			}
+			// 目录下任何文件变化都检查一次，软链接切换时变化的不是配置文件本身
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			timer.Reset(w.options.debounce)
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return
+			}
+			w.options.onError(fmt.Errorf("监听配置文件失败: %w", err))
+		case <-timer.C:
+			if _, err := w.reload(); err != nil {
+				w.options.onError(err)
+			}
+		}
+	}
+}
+
+func (w *Watcher[T]) poll() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.options.interval)
+	defer ticker.Stop()
+	var modTime time.Time
+	var size int64
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(w.Path)
+			if err != nil {
+				w.options.onError(fmt.Errorf("读取配置文件失败: %w", err))
+				continue
+			}
+			if info.ModTime().Equal(modTime) && info.Size() == size {
+				continue
+			}
+			// 无效的修改只报告一次，等待下一次修改
+			modTime, size = info.ModTime(), info.Size()
+			if _, err := w.reload(); err != nil {
+				w.options.onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type watchConfig struct {
+	Port int `json:"port"`
+}
+
+func writeConfig(t *testing.T, path string, port int) {
+	t.Helper()
+	if err := os.WriteFile(path, fmt.Appendf(nil, `{"port": %d}`, port), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherReloadOrdering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfig(t, path, 0)
+	// 只通过 Reload 触发重新加载
+	w, err := NewWatcher[watchConfig](path, WithPolling[watchConfig](time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	var mu sync.Mutex
+	var calls []string
+	var changes [][2]int
+	w.Subscribe(func(old, new *watchConfig) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, "first")
+		changes = append(changes, [2]int{old.Port, new.Port})
+	})
+	cancel := w.Subscribe(func(old, new *watchConfig) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, "second")
+	})
+
+	var wg sync.WaitGroup
+	for port := 1; port <= 20; port++ {
+		writeConfig(t, path, port)
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.Reload()
+			}()
+		}
+	}
+	wg.Wait()
+	if err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Get().Port != 20 {
+		t.Fatalf("Get().Port = %d，应当为最后写入的 20", w.Get().Port)
+	}
+	// 每次通知的旧配置都是上一次通知的新配置，没有乱序或重复
+	prev := 0
+	for _, c := range changes {
+		if c[0] != prev || c[1] == c[0] {
+			t.Fatalf("通知顺序错误: %v", changes)
+		}
+		prev = c[1]
+	}
+	if prev != 20 {
+		t.Fatalf("最后一次通知的新配置为 %d", prev)
+	}
+	for i := 0; i < len(calls); i += 2 {
+		if calls[i] != "first" || calls[i+1] != "second" {
+			t.Fatalf("订阅者没有按订阅顺序调用: %v", calls)
+		}
+	}
+
+	cancel()
+	n := len(calls)
+	writeConfig(t, path, 21)
+	w.Reload()
+	if len(calls) != n+1 {
+		t.Fatal("取消订阅后仍然收到通知")
+	}
+}
+
+func TestWatcherKeepsLastValid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfig(t, path, 1)
+	w, err := NewWatcher(path,
+		WithPolling[watchConfig](time.Hour),
+		WithValidate(func(c *watchConfig) error {
+			if c.Port > 65535 {
+				return errors.New("端口无效")
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	notified := false
+	w.Subscribe(func(old, new *watchConfig) { notified = true })
+
+	os.WriteFile(path, []byte(`{"port":`), 0o644)
+	if err := w.Reload(); err == nil {
+		t.Fatal("解析失败时应当返回错误")
+	}
+	writeConfig(t, path, 70000)
+	if err := w.Reload(); err == nil {
+		t.Fatal("校验失败时应当返回错误")
+	}
+	if w.Get().Port != 1 || notified {
+		t.Fatal("无效的修改不应替换配置")
+	}
+
+	// 内容未变化时不通知
+	writeConfig(t, path, 1)
+	w.Reload()
+	if notified {
+		t.Fatal("内容未变化时不应通知订阅者")
+	}
+}
+
+func TestWatcherDetectsChanges(t *testing.T) {
+	for name, opt := range map[string]WatchOption[watchConfig]{
+		"notify":  WithDebounce[watchConfig](10 * time.Millisecond),
+		"polling": WithPolling[watchConfig](20 * time.Millisecond),
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			writeConfig(t, path, 1)
+			w, err := NewWatcher(path, opt, WithErrorHandler[watchConfig](func(error) {}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			changed := make(chan int, 10)
+			w.Subscribe(func(old, new *watchConfig) { changed <- new.Port })
+
+			// 轮询按修改时间和大小判断，写入长度不同的内容
+			writeConfig(t, path, 12345)
+			select {
+			case port := <-changed:
+				if port != 12345 {
+					t.Fatalf("Port = %d", port)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatal("没有检测到配置文件变化")
+			}
+		})
+	}
+}
+
+func TestWatcherDefaultsAndValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"pool": {"addr": "127.0.0.1:6379"}}`), 0o644)
+	w, err := NewWatcher[testPoolConfig](path, WithPolling[testPoolConfig](time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if pool := w.Get().Pool; pool == nil || pool.Mode != "single" {
+		t.Fatalf("首次加载应当填充默认值: %+v", pool)
+	}
+
+	os.WriteFile(path, []byte(`{"pool": {"mode": "sentinel"}}`), 0o644)
+	var verr *ValidationError
+	if err := w.Reload(); !errors.As(err, &verr) {
+		t.Fatalf("重新加载时应当按 validate 标签校验，实际为 %v", err)
+	}
+	if w.Get().Pool.Addr != "127.0.0.1:6379" {
+		t.Fatal("校验失败时不应替换配置")
+	}
+}
@@ -1,24 +1,10 @@
 package config
 
-import (
-	"fmt"
-	"os"
-
-	"sigs.k8s.io/yaml"
-)
-
+// NewYAML 解析yaml配置文件，填充 default 标签的默认值并按 validate 标签校验
 func NewYAML[T any](path string) (*T, error) {
-	file, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %w", err)
-	}
-	var cfg T
-	if err := yaml.Unmarshal(file, &cfg); err != nil {
-		return nil, fmt.Errorf("解析yaml类型配置文件失败: %w", err)
-	}
-	return &cfg, nil
+	return parse[T](path, "yaml")
 }
 
 func LoadYAML[T any](path string) (*T, error) {
 	return load(path, NewYAML[T])
-}
\ No newline at end of file
+}
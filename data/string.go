@@ -1,18 +1,22 @@
 ﻿package data
 
 import (
+	crand "crypto/rand"
 	"fmt"
+	"math/big"
 	"math/rand"
 	"strconv"
 	"strings"
 )
 
-// GenerateRandomAlphanumeric 生成随机字母数字字符串
+// GenerateRandomAlphanumeric 生成随机字母数字字符串，使用 crypto/rand，可用于验证码、邀请码等场景
 func GenerateRandomAlphanumeric(length int) string {
 	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	result := make([]byte, length)
 	for i := range result {
-		result[i] = chars[rand.Intn(len(chars))]
+		// crypto/rand.Reader 不会返回错误
+		n, _ := crand.Int(crand.Reader, big.NewInt(int64(len(chars))))
+		result[i] = chars[n.Int64()]
 	}
 	return string(result)
 }
@@ -3,14 +3,15 @@ package email
 import (
 	"fmt"
 
+	"github.com/ligaolin/goweb/v2/validator"
 	"gopkg.in/gomail.v2"
 )
 
 type EmailConfig struct {
-	Smtp     string
-	Port     int
-	Email    string
-	Password string
+	Smtp     string `validate:"required:smtp服务器地址必须"`
+	Port     int    `validate:"between=1,65535:无效的端口号"`
+	Email    string `validate:"required:邮箱地址必须"`
+	Password string `validate:"required:邮箱密码必须"`
 	FromName string
 }
 
@@ -20,7 +21,7 @@ type Email struct {
 }
 
 func New(cfg *EmailConfig) (*Email, error) {
-	if err := validateConfig(cfg); err != nil {
+	if err := validator.Validator(cfg); err != nil {
 		return nil, err
 	}
 
@@ -30,22 +31,6 @@ func New(cfg *EmailConfig) (*Email, error) {
 	}, nil
 }
 
-func validateConfig(cfg *EmailConfig) error {
-	if cfg.Smtp == "" {
-		return fmt.Errorf("smtp服务器地址必须")
-	}
-	if cfg.Port <= 0 || cfg.Port > 65535 {
-		return fmt.Errorf("无效的端口号")
-	}
-	if cfg.Email == "" {
-		return fmt.Errorf("邮箱地址必须")
-	}
-	if cfg.Password == "" {
-		return fmt.Errorf("邮箱密码必须")
-	}
-	return nil
-}
-
 func (e *Email) Send(to []string, subject, body string, opts ...Option) error {
 	if err := e.validateSendParams(to, subject); err != nil {
 		return err
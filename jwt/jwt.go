@@ -8,9 +8,9 @@ import (
 )
 
 type JwtConfig struct {
-	Expir  int64 // jwt登录过期时间，分钟，1440一天
+	Expir  int64 `default:"1440" validate:"between=1,525600:jwt过期时间无效"` // jwt登录过期时间，分钟，1440一天
 	Issuer string
-	Sign   string
+	Sign   string `validate:"required:jwt签名密钥必须"`
 }
 
 type Claims struct {
@@ -19,6 +19,8 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+var errSign = errors.New("jwt签名密钥必须")
+
 type Jwt struct {
 	Config *JwtConfig
 }
@@ -28,6 +30,9 @@ func NewJwt(cfg *JwtConfig) *Jwt {
 }
 
 func (j *Jwt) Set(id int32, types string) (string, error) {
+	if j.Config.Sign == "" {
+		return "", errSign
+	}
 	claims := Claims{
 		ID:   id,
 		Type: types,
@@ -40,6 +45,9 @@ func (j *Jwt) Set(id int32, types string) (string, error) {
 }
 
 func (j *Jwt) Get(t string, claims *Claims) error {
+	if j.Config.Sign == "" {
+		return errSign
+	}
 	token, err := jwt.ParseWithClaims(t, claims, func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("签名算法无效")
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// fixedWindow 固定窗口计数
+type fixedWindow struct {
+	mu     sync.Mutex
+	cache  cache.Cache
+	config *Config
+}
+
+type fixedState struct {
+	Count   int
+	ResetAt time.Time
+}
+
+func (l *fixedWindow) Allow(ctx context.Context, key string) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key = l.config.Prefix + key
+	now := time.Now()
+	var state fixedState
+	found, err := load(l.cache, key, &state)
+	if err != nil {
+		return nil, err
+	}
+	if !found || !now.Before(state.ResetAt) {
+		state = fixedState{ResetAt: now.Add(l.config.Window)}
+	}
+	state.Count++
+	if err := l.cache.Set(key, state, state.ResetAt.Sub(now)); err != nil {
+		return nil, err
+	}
+	return fixedResult(l.config.Limit, state.Count, state.ResetAt, now), nil
+}
+
+// fixedWindowScript 计数器没有过期时间时（例如 PEXPIRE 前进程中断或被外部修改）重新设置，避免窗口永不重置
+var fixedWindowScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if count == 1 or ttl < 0 then
+	ttl = tonumber(ARGV[1])
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return {count, ttl}
+`)
+
+type redisFixedWindow struct {
+	client redis.UniversalClient
+	config *Config
+}
+
+func (l *redisFixedWindow) Allow(ctx context.Context, key string) (*Result, error) {
+	res, err := fixedWindowScript.Run(ctx, l.client, []string{l.config.Prefix + key}, l.config.Window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return fixedResult(l.config.Limit, int(res[0]), now.Add(time.Duration(res[1])*time.Millisecond), now), nil
+}
+
+func fixedResult(limit int, count int, resetAt time.Time, now time.Time) *Result {
+	res := &Result{
+		Allowed:   count <= limit,
+		Limit:     limit,
+		Remaining: max(limit-count, 0),
+		ResetAt:   resetAt,
+	}
+	if !res.Allowed {
+		res.RetryAfter = resetAt.Sub(now)
+	}
+	return res
+}
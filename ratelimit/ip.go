@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// IPResolver 从受信任的反向代理转发的请求头中获取客户端 IP
+type IPResolver struct {
+	TrustedProxies []*net.IPNet // 受信任的代理网段，只有来自这些地址的请求才会读取转发请求头
+}
+
+// NewIPResolver 根据 CIDR 创建 IPResolver，例如 10.0.0.0/8、127.0.0.1/32，单个 IP 视为 /32 或 /128
+func NewIPResolver(cidrs ...string) (*IPResolver, error) {
+	r := &IPResolver{}
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("受信任代理地址无效: %w", err)
+		}
+		r.TrustedProxies = append(r.TrustedProxies, network)
+	}
+	return r, nil
+}
+
+// ClientIP 对端地址是受信任的代理时，从 X-Forwarded-For 右侧开始跳过受信任的代理，
+// 取第一个不受信任的地址；没有 X-Forwarded-For 时使用 X-Real-IP；否则使用对端地址。
+// 方法值可以直接作为 Middleware 的 key 使用
+func (p *IPResolver) ClientIP(r *http.Request) string {
+	ip := remoteIP(r)
+	if !p.trusted(ip) {
+		return ip
+	}
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// 无法解析的地址不可信，停在最后一个可信的地址
+				return ip
+			}
+			ip = hop
+			if !p.trusted(hop) {
+				return hop
+			}
+		}
+		return ip
+	}
+	if real := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(real) != nil {
+		return real
+	}
+	return ip
+}
+
+func (p *IPResolver) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range p.TrustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP 连接的对端地址
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKeyByIPIgnoresHeaders(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:5000"
+	r.Header.Set("X-Real-IP", "1.1.1.1")
+	r.Header.Set("X-Forwarded-For", "2.2.2.2")
+	if ip := KeyByIP(r); ip != "203.0.113.7" {
+		t.Fatalf("KeyByIP = %s，不应信任请求头", ip)
+	}
+}
+
+func TestIPResolver(t *testing.T) {
+	resolver, err := NewIPResolver("10.0.0.0/8", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewIPResolver("bad"); err == nil {
+		t.Fatal("无效的网段应当返回错误")
+	}
+
+	tests := []struct {
+		remote    string
+		forwarded string
+		realIP    string
+		want      string
+	}{
+		// 不受信任的对端伪造请求头
+		{"203.0.113.7:5000", "2.2.2.2", "1.1.1.1", "203.0.113.7"},
+		// 受信任的代理，取最右侧不受信任的地址，左侧由客户端填写的地址被忽略
+		{"10.0.0.1:5000", "6.6.6.6, 198.51.100.1, 10.0.0.2", "", "198.51.100.1"},
+		{"127.0.0.1:5000", "", "198.51.100.1", "198.51.100.1"},
+		// 全部是受信任的代理
+		{"10.0.0.1:5000", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"},
+		// 无法解析的地址
+		{"10.0.0.1:5000", "198.51.100.1, garbage", "", "10.0.0.1"},
+		{"10.0.0.1:5000", "", "", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remote
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if tt.realIP != "" {
+			r.Header.Set("X-Real-IP", tt.realIP)
+		}
+		if ip := resolver.ClientIP(r); ip != tt.want {
+			t.Errorf("RemoteAddr=%s X-Forwarded-For=%q X-Real-IP=%q: ClientIP = %s，应当为 %s", tt.remote, tt.forwarded, tt.realIP, ip, tt.want)
+		}
+	}
+}
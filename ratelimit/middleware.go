@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ligaolin/goweb/v2/response"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// KeyByIP 以连接的对端地址作为限流键。请求头中的 X-Real-IP、X-Forwarded-For 可以被客户端随意伪造，
+// 这里不会读取；部署在反向代理之后时使用 NewIPResolver 指定受信任的代理
+func KeyByIP(r *http.Request) string {
+	return remoteIP(r)
+}
+
+// Middleware net/http 限流中间件，key 为空时使用 KeyByIP。
+// 缓存出错时放行请求并记录日志，避免缓存故障导致整个服务不可用
+func Middleware(l Limiter, key func(*http.Request) string) func(http.Handler) http.Handler {
+	if key == nil {
+		key = KeyByIP
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allow(r.Context(), l, key(r), w) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// Gin gin 限流中间件，key 为空时使用 KeyByIP
+func Gin(l Limiter, key func(*gin.Context) string) gin.HandlerFunc {
+	if key == nil {
+		key = func(c *gin.Context) string {
+			return KeyByIP(c.Request)
+		}
+	}
+	return func(c *gin.Context) {
+		if !allow(c.Request.Context(), l, key(c), c.Writer) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// allow 执行限流并写入 RateLimit-* 响应头，被拒绝时直接输出响应
+func allow(ctx context.Context, l Limiter, key string, w http.ResponseWriter) bool {
+	res, err := l.Allow(ctx, key)
+	if err != nil {
+		logc.Errorf(ctx, "限流检查失败: %v", err)
+		return true
+	}
+
+	SetHeaders(w.Header(), res)
+	if res.Allowed {
+		return true
+	}
+	response.NewResponse(w).
+		SetCode(http.StatusTooManyRequests).
+		SetMessage("请求过于频繁，请稍后再试").
+		Write()
+	return false
+}
+
+// SetHeaders 写入 RateLimit-Limit、RateLimit-Remaining、RateLimit-Reset，被拒绝时还会写入 Retry-After
+func SetHeaders(h http.Header, res *Result) {
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(time.Until(res.ResetAt))))
+	if !res.Allowed {
+		h.Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+	}
+}
+
+// seconds 向上取整到秒
+func seconds(d time.Duration) int {
+	return max(int(math.Ceil(d.Seconds())), 0)
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+type Config struct {
+	Algorithm string        // 限流算法：fixed 固定窗口、sliding 滑动日志、token 令牌桶，默认 fixed
+	Limit     int           // 窗口内允许的请求数，令牌桶为桶容量，必须大于 0
+	Window    time.Duration // 窗口长度，令牌桶每个 Window 补满 Limit 个令牌，不能小于 1 毫秒
+	Prefix    string        // 缓存键前缀，默认 ratelimit:
+}
+
+type Result struct {
+	Allowed    bool          // 是否放行
+	Limit      int           // 配额上限
+	Remaining  int           // 剩余配额
+	ResetAt    time.Time     // 配额完全恢复的时间
+	RetryAfter time.Duration // 被拒绝时建议的等待时长
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string) (*Result, error)
+}
+
+// New 创建限流器，状态保存在缓存中。
+// Redis（包括二级缓存的 Redis 层，以及 cache.Metrics 包装的 Redis）使用 Lua 脚本保证多实例下的原子性，其他后端在进程内加锁
+func New(c cache.Cache, config *Config) (Limiter, error) {
+	if config.Limit <= 0 {
+		return nil, errors.New("限流配额必须大于 0")
+	}
+	// 各算法以毫秒为单位计算，窗口小于 1 毫秒时会除以 0
+	if config.Window < time.Millisecond {
+		return nil, errors.New("限流窗口不能小于 1 毫秒")
+	}
+	// 复制一份配置，不修改调用方传入的结构
+	scoped := *config
+	config = &scoped
+	if config.Prefix == "" {
+		config.Prefix = "ratelimit:"
+	}
+
+	var (
+		client redis.UniversalClient
+		prefix string
+	)
+	switch r := unwrap(c).(type) {
+	case *cache.Redis:
+		client, prefix = r.Client, r.Prefix
+	case *cache.Tiered:
+		client, prefix = r.Remote.Client, r.Remote.Prefix
+	}
+	// Lua 脚本直接操作 Redis，需要自行加上缓存的键前缀
+	config.Prefix = prefix + config.Prefix
+
+	switch config.Algorithm {
+	case "sliding":
+		if client != nil {
+			return &redisSlidingLog{client: client, config: config}, nil
+		}
+		return &slidingLog{cache: c, config: config}, nil
+	case "token":
+		if client != nil {
+			return &redisTokenBucket{client: client, config: config}, nil
+		}
+		return &tokenBucket{cache: c, config: config}, nil
+	default:
+		if client != nil {
+			return &redisFixedWindow{client: client, config: config}, nil
+		}
+		return &fixedWindow{cache: c, config: config}, nil
+	}
+}
+
+// load 读取进程内算法保存的状态，数据不存在时返回 false，其他错误原样返回，避免缓存故障时状态被重置而放行
+func load(c cache.Cache, key string, state any) (bool, error) {
+	err := c.Get(key, state)
+	if errors.Is(err, cache.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unwrap 取出 cache.Metrics 等包装下的底层缓存，用于判断是否可以使用 Lua 脚本
+func unwrap(c cache.Cache) cache.Cache {
+	for {
+		w, ok := c.(interface{ Unwrap() cache.Cache })
+		if !ok {
+			return c
+		}
+		c = w.Unwrap()
+	}
+}
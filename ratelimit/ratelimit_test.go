@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ligaolin/goweb/v2/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// testCaches 返回内存缓存和使用 miniredis 的 Redis 缓存
+func testCaches(t *testing.T) (map[string]cache.Cache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	memory := cache.NewMemory(nil)
+	t.Cleanup(func() {
+		client.Close()
+		memory.Close()
+	})
+	return map[string]cache.Cache{
+		"memory": memory,
+		"redis":  &cache.Redis{Client: client, Prefix: "test:"},
+	}, mr
+}
+
+func TestAlgorithms(t *testing.T) {
+	ctx := context.Background()
+	caches, mr := testCaches(t)
+	for name, c := range caches {
+		for _, algorithm := range []string{"fixed", "sliding", "token"} {
+			t.Run(name+"/"+algorithm, func(t *testing.T) {
+				l, err := New(c, &Config{Algorithm: algorithm, Prefix: algorithm + ":", Limit: 3, Window: 200 * time.Millisecond})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := range 3 {
+					res, err := l.Allow(ctx, "user")
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !res.Allowed || res.Remaining != 2-i {
+						t.Fatalf("第 %d 次请求: Allowed=%v Remaining=%d", i+1, res.Allowed, res.Remaining)
+					}
+				}
+				res, err := l.Allow(ctx, "user")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Allowed || res.RetryAfter <= 0 {
+					t.Fatalf("超过配额应当被拒绝: Allowed=%v RetryAfter=%v", res.Allowed, res.RetryAfter)
+				}
+				// 不同的键互不影响
+				if res, _ := l.Allow(ctx, "other"); !res.Allowed {
+					t.Fatal("其他键不应被限流")
+				}
+
+				time.Sleep(250 * time.Millisecond)
+				// miniredis 的过期时间需要手动推进
+				mr.FastForward(250 * time.Millisecond)
+				if res, _ := l.Allow(ctx, "user"); !res.Allowed {
+					t.Fatal("窗口过后应当恢复配额")
+				}
+			})
+		}
+	}
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	c := cache.NewMemory(nil)
+	defer c.Close()
+	for _, config := range []*Config{
+		{Limit: 0, Window: time.Second},
+		{Limit: -1, Window: time.Second},
+		{Limit: 1, Window: 0},
+		{Limit: 1, Window: time.Microsecond},
+	} {
+		if _, err := New(c, config); err == nil {
+			t.Fatalf("Limit=%d Window=%v 应当返回错误", config.Limit, config.Window)
+		}
+	}
+}
+
+func TestNewUnwrapsMetrics(t *testing.T) {
+	caches, _ := testCaches(t)
+	l, err := New(cache.NewMetrics("test", caches["redis"]).Wrapped(), &Config{Limit: 1, Window: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l.(*redisFixedWindow); !ok {
+		t.Fatalf("统计包装下的 Redis 应当使用 Lua 脚本，实际为 %T", l)
+	}
+}
+
+func TestNewCopiesConfig(t *testing.T) {
+	caches, _ := testCaches(t)
+	for name, c := range caches {
+		config := &Config{Limit: 1, Window: time.Second}
+		if _, err := New(c, config); err != nil {
+			t.Fatal(err)
+		}
+		if config.Prefix != "" {
+			t.Fatalf("%s: New 修改了调用方的配置，Prefix 为 %q", name, config.Prefix)
+		}
+	}
+}
+
+// failingCache 读取总是失败，模拟缓存故障
+type failingCache struct {
+	cache.Cache
+}
+
+var errBroken = errors.New("缓存不可用")
+
+func (failingCache) Get(key string, value any) error {
+	return errBroken
+}
+
+func TestAlgorithmsReturnCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	memory := cache.NewMemory(nil)
+	defer memory.Close()
+	for _, algorithm := range []string{"fixed", "sliding", "token"} {
+		l, err := New(failingCache{memory}, &Config{Algorithm: algorithm, Limit: 1, Window: time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.Allow(ctx, "user"); !errors.Is(err, errBroken) {
+			t.Fatalf("%s: 读取状态失败时应当返回错误，实际为 %v", algorithm, err)
+		}
+	}
+}
+
+func TestRedisFixedWindowRestoresTTL(t *testing.T) {
+	ctx := context.Background()
+	caches, mr := testCaches(t)
+	l, err := New(caches["redis"], &Config{Limit: 10, Window: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 计数器丢失了过期时间
+	mr.Set("test:ratelimit:user", "3")
+
+	now := time.Now()
+	res, err := l.Allow(ctx, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.ResetAt.After(now) {
+		t.Fatalf("ResetAt 为 %v，应当晚于当前时间", res.ResetAt)
+	}
+	if ttl := mr.TTL("test:ratelimit:user"); ttl <= 0 || ttl > time.Second {
+		t.Fatalf("计数器的过期时间为 %v，应当重新设置为窗口长度", ttl)
+	}
+}
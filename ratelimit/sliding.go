@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ligaolin/goweb/v2/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingLog 滑动日志，记录窗口内每次放行的时间，精确但占用随 Limit 增长
+type slidingLog struct {
+	mu     sync.Mutex
+	cache  cache.Cache
+	config *Config
+}
+
+func (l *slidingLog) Allow(ctx context.Context, key string) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key = l.config.Prefix + key
+	now := time.Now()
+	start := now.Add(-l.config.Window).UnixMilli()
+
+	var hits []int64
+	if _, err := load(l.cache, key, &hits); err != nil {
+		return nil, err
+	}
+	valid := hits[:0]
+	for _, hit := range hits {
+		if hit > start {
+			valid = append(valid, hit)
+		}
+	}
+
+	allowed := len(valid) < l.config.Limit
+	if allowed {
+		valid = append(valid, now.UnixMilli())
+	}
+	if err := l.cache.Set(key, valid, l.config.Window); err != nil {
+		return nil, err
+	}
+	return slidingResult(l.config, allowed, len(valid), valid[0], now), nil
+}
+
+var slidingLogScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return {allowed, count, tonumber(oldest[2]) or now}
+`)
+
+type redisSlidingLog struct {
+	client redis.UniversalClient
+	config *Config
+}
+
+func (l *redisSlidingLog) Allow(ctx context.Context, key string) (*Result, error) {
+	now := time.Now()
+	res, err := slidingLogScript.Run(ctx, l.client, []string{l.config.Prefix + key},
+		now.UnixMilli(), l.config.Window.Milliseconds(), l.config.Limit, uuid.New().String()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	return slidingResult(l.config, res[0] == 1, int(res[1]), res[2], now), nil
+}
+
+// slidingResult 最早一次放行的记录滑出窗口后才会空出配额
+func slidingResult(config *Config, allowed bool, count int, oldest int64, now time.Time) *Result {
+	res := &Result{
+		Allowed:   allowed,
+		Limit:     config.Limit,
+		Remaining: max(config.Limit-count, 0),
+		ResetAt:   time.UnixMilli(oldest).Add(config.Window),
+	}
+	if !allowed {
+		res.RetryAfter = res.ResetAt.Sub(now)
+	}
+	return res
+}
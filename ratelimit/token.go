@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucket 令牌桶，允许短时突发 Limit 个请求，之后按 Limit/Window 的速率放行
+type tokenBucket struct {
+	mu     sync.Mutex
+	cache  cache.Cache
+	config *Config
+}
+
+type tokenState struct {
+	Tokens  float64
+	Updated int64 // 上次补充令牌的时间，毫秒
+}
+
+func (l *tokenBucket) Allow(ctx context.Context, key string) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key = l.config.Prefix + key
+	now := time.Now()
+	state := tokenState{Tokens: float64(l.config.Limit), Updated: now.UnixMilli()}
+	if _, err := load(l.cache, key, &state); err != nil {
+		return nil, err
+	}
+
+	rate := tokenRate(l.config)
+	state.Tokens = math.Min(float64(l.config.Limit), state.Tokens+float64(now.UnixMilli()-state.Updated)*rate)
+	state.Updated = now.UnixMilli()
+	allowed := state.Tokens >= 1
+	if allowed {
+		state.Tokens--
+	}
+	if err := l.cache.Set(key, state, l.config.Window); err != nil {
+		return nil, err
+	}
+	return tokenResult(l.config, allowed, state.Tokens, now), nil
+}
+
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local window = tonumber(ARGV[4])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or limit
+local updated = tonumber(state[2]) or now
+tokens = math.min(limit, tokens + math.max(now - updated, 0) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, tostring(tokens)}
+`)
+
+type redisTokenBucket struct {
+	client redis.UniversalClient
+	config *Config
+}
+
+func (l *redisTokenBucket) Allow(ctx context.Context, key string) (*Result, error) {
+	now := time.Now()
+	res, err := tokenBucketScript.Run(ctx, l.client, []string{l.config.Prefix + key},
+		l.config.Limit, strconv.FormatFloat(tokenRate(l.config), 'g', -1, 64), now.UnixMilli(), l.config.Window.Milliseconds()).Slice()
+	if err != nil {
+		return nil, err
+	}
+	// Lua 返回的小数会被截断为整数，因此剩余令牌数以字符串返回
+	tokens, err := strconv.ParseFloat(res[1].(string), 64)
+	if err != nil {
+		return nil, err
+	}
+	return tokenResult(l.config, res[0].(int64) == 1, tokens, now), nil
+}
+
+// tokenRate 每毫秒补充的令牌数
+func tokenRate(config *Config) float64 {
+	return float64(config.Limit) / float64(config.Window.Milliseconds())
+}
+
+// tokenResult ResetAt 为令牌桶补满的时间
+func tokenResult(config *Config, allowed bool, tokens float64, now time.Time) *Result {
+	rate := tokenRate(config)
+	res := &Result{
+		Allowed:   allowed,
+		Limit:     config.Limit,
+		Remaining: int(tokens),
+		ResetAt:   now.Add(time.Duration((float64(config.Limit)-tokens)/rate) * time.Millisecond),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1-tokens)/rate) * time.Millisecond
+	}
+	return res
+}
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ligaolin/goweb/v2/response"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+type contextKey struct{}
+
+// FromContext 取出中间件加载的 Session，gin 中使用 FromContext(c.Request.Context())
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(contextKey{}).(*Session)
+	return s
+}
+
+// Middleware net/http Session 中间件，请求开始时加载 Session，在首次输出响应前自动保存
+func Middleware(m *Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, ok := m.load(w, r)
+			if !ok {
+				return
+			}
+			sw := &responseWriter{ResponseWriter: w, save: m.saver(r.Context(), w, s)}
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), contextKey{}, s)))
+			sw.once.Do(sw.save)
+		})
+	}
+}
+
+// Gin gin Session 中间件
+func Gin(m *Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s, ok := m.load(c.Writer, c.Request)
+		if !ok {
+			c.Abort()
+			return
+		}
+		gw := &ginWriter{ResponseWriter: c.Writer, save: m.saver(c.Request.Context(), c.Writer, s)}
+		c.Writer = gw
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), contextKey{}, s))
+		c.Next()
+		gw.once.Do(gw.save)
+	}
+}
+
+// load 加载 Session，缓存出错时直接输出错误响应
+func (m *Manager) load(w http.ResponseWriter, r *http.Request) (*Session, bool) {
+	s, err := m.Load(r)
+	if err != nil {
+		logc.Errorf(r.Context(), "加载Session失败: %v", err)
+		response.NewResponse(w).
+			SetCode(http.StatusInternalServerError).
+			SetMessage("加载会话失败").
+			Write()
+		return nil, false
+	}
+	return s, true
+}
+
+func (m *Manager) saver(ctx context.Context, w http.ResponseWriter, s *Session) func() {
+	return func() {
+		if err := m.Save(ctx, w, s); err != nil {
+			logc.Errorf(ctx, "保存Session失败: %v", err)
+		}
+	}
+}
+
+// responseWriter 在写入响应头之前保存 Session，确保 Set-Cookie 能够发出
+type responseWriter struct {
+	http.ResponseWriter
+	save func()
+	once sync.Once
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.once.Do(w.save)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.once.Do(w.save)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type ginWriter struct {
+	gin.ResponseWriter
+	save func()
+	once sync.Once
+}
+
+func (w *ginWriter) WriteHeader(code int) {
+	w.once.Do(w.save)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *ginWriter) WriteHeaderNow() {
+	w.once.Do(w.save)
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *ginWriter) Write(b []byte) (int, error) {
+	w.once.Do(w.save)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *ginWriter) WriteString(s string) (int, error) {
+	w.once.Do(w.save)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,317 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ligaolin/goweb/v2/cache"
+	"github.com/ligaolin/goweb/v2/data"
+)
+
+type Config struct {
+	Key        []byte        // 加密 Cookie 的 AES 密钥，长度必须为 16、24 或 32 字节
+	CookieName string        // Cookie 名称，默认 session_id
+	MaxAge     time.Duration // 空闲超时，每次请求后重新计时，默认 30 分钟
+	Path       string        // Cookie 路径，默认 /
+	Domain     string        // Cookie 域名
+	Secure     bool          // 只通过 HTTPS 发送 Cookie
+	SameSite   http.SameSite // 默认 Lax
+	Prefix     string        // 缓存键前缀，默认 session:
+}
+
+// Manager 将 Session 数据保存在缓存中，Cookie 中只保存加密后的 Session ID，
+// 服务端删除数据即可让 Session 立即失效
+type Manager struct {
+	cache  cache.Cache
+	config *Config
+}
+
+func NewManager(c cache.Cache, config *Config) (*Manager, error) {
+	switch len(config.Key) {
+	case 16, 24, 32:
+	default:
+		return nil, errors.New("Session密钥长度必须为16、24或32字节")
+	}
+	if config.CookieName == "" {
+		config.CookieName = "session_id"
+	}
+	if config.MaxAge <= 0 {
+		config.MaxAge = 30 * time.Minute
+	}
+	if config.Path == "" {
+		config.Path = "/"
+	}
+	if config.SameSite == 0 {
+		config.SameSite = http.SameSiteLaxMode
+	}
+	if config.Prefix == "" {
+		config.Prefix = "session:"
+	}
+	return &Manager{cache: c, config: config}, nil
+}
+
+// Session 一次请求内使用的会话数据。
+// 值在缓存中以 Codec 序列化保存，使用 JSON 时数字读取后为 float64
+type Session struct {
+	ID        string
+	UserID    string // 通过 SetUser 绑定的用户，用于 DestroyUser
+	CreatedAt time.Time
+
+	mu        sync.Mutex
+	values    map[string]any
+	flashes   map[string][]any
+	isNew     bool
+	dirty     bool
+	destroyed bool
+	oldID     string // Regenerate 前的 ID，保存时删除
+}
+
+// record Session 在缓存中的保存格式
+type record struct {
+	UserID    string
+	Values    map[string]any
+	Flashes   map[string][]any
+	CreatedAt time.Time
+}
+
+func (s *Session) Get(key string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+func (s *Session) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	s.dirty = true
+}
+
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+	s.dirty = true
+}
+
+// AddFlash 添加一次性消息，通过 Flashes 读取后即被删除，常用于重定向后显示提示
+func (s *Session) AddFlash(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flashes[key] = append(s.flashes[key], value)
+	s.dirty = true
+}
+
+// Flashes 读取并删除 key 下的所有一次性消息
+func (s *Session) Flashes(key string) []any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values, ok := s.flashes[key]
+	if ok {
+		delete(s.flashes, key)
+		s.dirty = true
+	}
+	return values
+}
+
+// SetUser 将 Session 绑定到用户，之后可以通过 Manager.DestroyUser 销毁该用户的所有 Session
+func (s *Session) SetUser(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.UserID = userID
+	s.dirty = true
+}
+
+// Regenerate 更换 Session ID 并保留数据，登录或提升权限后调用以防止会话固定攻击
+func (s *Session) Regenerate() error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isNew && s.oldID == "" {
+		s.oldID = s.ID
+	}
+	s.ID = id
+	s.dirty = true
+	return nil
+}
+
+// Destroy 销毁 Session，保存时删除缓存数据并清除 Cookie
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.destroyed = true
+}
+
+// Load 根据请求中的 Cookie 加载 Session，Cookie 不存在、无法解密或数据已过期时返回新的 Session
+func (m *Manager) Load(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(m.config.CookieName)
+	if err != nil {
+		return m.newSession()
+	}
+	id, err := data.Decrypt(m.config.Key, cookie.Value)
+	if err != nil {
+		return m.newSession()
+	}
+
+	var rec record
+	if err := m.get(r.Context(), m.key(id), &rec); err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return m.newSession()
+		}
+		return nil, err
+	}
+	s := &Session{
+		ID:        id,
+		UserID:    rec.UserID,
+		CreatedAt: rec.CreatedAt,
+		values:    rec.Values,
+		flashes:   rec.Flashes,
+	}
+	if s.values == nil {
+		s.values = make(map[string]any)
+	}
+	if s.flashes == nil {
+		s.flashes = make(map[string][]any)
+	}
+	return s, nil
+}
+
+// Save 保存 Session 并写入 Cookie，必须在输出响应之前调用。
+// 每次保存都会重置有效期；未修改过的新 Session 不会保存，也不会下发 Cookie
+func (m *Manager) Save(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.oldID != "" {
+		if err := m.delete(ctx, m.key(s.oldID)); err != nil {
+			return err
+		}
+		s.oldID = ""
+	}
+	if s.destroyed {
+		if !s.isNew {
+			if err := m.delete(ctx, m.key(s.ID)); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, m.cookie("", -1))
+		return nil
+	}
+	if s.isNew && !s.dirty {
+		return nil
+	}
+
+	if err := m.store(ctx, s); err != nil {
+		return err
+	}
+	value, err := data.Encrypt(m.config.Key, s.ID)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, m.cookie(value, int(m.config.MaxAge.Seconds())))
+	s.isNew = false
+	s.dirty = false
+	return nil
+}
+
+// DestroyUser 销毁用户的所有 Session，例如修改密码或封禁账号后强制下线，需要缓存实现 cache.Tagger
+func (m *Manager) DestroyUser(ctx context.Context, userID string) error {
+	t, ok := m.cache.(cache.Tagger)
+	if !ok {
+		return errors.New("当前缓存不支持按用户销毁Session")
+	}
+	return t.InvalidateTag(ctx, m.userTag(userID))
+}
+
+// store 写入 Session 数据。数据未修改且没有绑定用户时只延长有效期；
+// 绑定了用户时总是重新写入，使用户索引的有效期随之延长
+func (m *Manager) store(ctx context.Context, s *Session) error {
+	key := m.key(s.ID)
+	if store, ok := m.cache.(cache.Store); ok && !s.dirty && s.UserID == "" {
+		err := store.Expire(ctx, key, m.config.MaxAge)
+		if !errors.Is(err, cache.ErrNotFound) {
+			return err
+		}
+	}
+
+	rec := record{
+		UserID:    s.UserID,
+		Values:    s.values,
+		Flashes:   s.flashes,
+		CreatedAt: s.CreatedAt,
+	}
+	if t, ok := m.cache.(cache.Tagger); ok && s.UserID != "" {
+		return t.SetWithTags(ctx, key, rec, m.config.MaxAge, m.userTag(s.UserID))
+	}
+	if store, ok := m.cache.(cache.Store); ok {
+		return store.SetWithContext(ctx, key, rec, m.config.MaxAge)
+	}
+	return m.cache.Set(key, rec, m.config.MaxAge)
+}
+
+func (m *Manager) get(ctx context.Context, key string, value any) error {
+	if store, ok := m.cache.(cache.Store); ok {
+		return store.GetWithContext(ctx, key, value)
+	}
+	return m.cache.Get(key, value)
+}
+
+func (m *Manager) delete(ctx context.Context, key string) error {
+	if store, ok := m.cache.(cache.Store); ok {
+		return store.DeleteWithContext(ctx, key)
+	}
+	return m.cache.Delete(key)
+}
+
+func (m *Manager) newSession() (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		ID:        id,
+		CreatedAt: time.Now(),
+		values:    make(map[string]any),
+		flashes:   make(map[string][]any),
+		isNew:     true,
+	}, nil
+}
+
+func (m *Manager) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.config.CookieName,
+		Value:    value,
+		Path:     m.config.Path,
+		Domain:   m.config.Domain,
+		MaxAge:   maxAge,
+		Secure:   m.config.Secure,
+		HttpOnly: true,
+		SameSite: m.config.SameSite,
+	}
+}
+
+func (m *Manager) key(id string) string {
+	return m.config.Prefix + id
+}
+
+func (m *Manager) userTag(userID string) string {
+	return m.config.Prefix + "user:" + userID
+}
+
+// newID 生成 256 位随机 ID
+func newID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,158 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ligaolin/goweb/v2/cache"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	memory := cache.NewMemory(nil)
+	t.Cleanup(func() { memory.Close() })
+	m, err := NewManager(memory, &Config{Key: []byte("0123456789abcdef")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// roundTrip 用 cookie 加载 Session，执行 fn 后保存，返回响应中的 Cookie
+func roundTrip(t *testing.T, m *Manager, cookie *http.Cookie, fn func(s *Session)) (*Session, *http.Cookie) {
+	t.Helper()
+	r := httptest.NewRequest("GET", "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	s, err := m.Load(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fn != nil {
+		fn(s)
+	}
+	rec := httptest.NewRecorder()
+	if err := m.Save(context.Background(), rec, s); err != nil {
+		t.Fatal(err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) == 0 {
+		return s, nil
+	}
+	return s, cookies[0]
+}
+
+func TestSessionRegenerate(t *testing.T) {
+	m := newTestManager(t)
+	first, cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Set("cart", "apple")
+	})
+	if cookie == nil {
+		t.Fatal("修改过的新 Session 应当下发 Cookie")
+	}
+
+	var oldID string
+	regenerated, newCookie := roundTrip(t, m, cookie, func(s *Session) {
+		oldID = s.ID
+		if err := s.Regenerate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if oldID != first.ID || regenerated.ID == oldID || newCookie == nil || newCookie.Value == cookie.Value {
+		t.Fatal("Regenerate 后应当下发新的 Session ID")
+	}
+
+	// 旧 ID 立即失效，数据随新 ID 保留
+	old, _ := roundTrip(t, m, cookie, nil)
+	if _, ok := old.Get("cart"); ok || old.ID == oldID {
+		t.Fatal("旧的 Session ID 仍然有效")
+	}
+	current, _ := roundTrip(t, m, newCookie, nil)
+	if v, _ := current.Get("cart"); v != "apple" || current.ID != regenerated.ID {
+		t.Fatalf("Regenerate 后数据丢失: %v", v)
+	}
+}
+
+func TestSessionRegenerateNew(t *testing.T) {
+	m := newTestManager(t)
+	// 新 Session 没有旧数据需要删除
+	s, cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Regenerate()
+		s.Set("k", "v")
+	})
+	if cookie == nil || s.oldID != "" {
+		t.Fatal("保存新 Session 失败")
+	}
+}
+
+func TestSessionUntouchedNotSaved(t *testing.T) {
+	m := newTestManager(t)
+	if _, cookie := roundTrip(t, m, nil, nil); cookie != nil {
+		t.Fatal("未修改的新 Session 不应下发 Cookie")
+	}
+}
+
+func TestSessionFlashes(t *testing.T) {
+	m := newTestManager(t)
+	_, cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.AddFlash("notice", "saved")
+	})
+	var flashes []any
+	roundTrip(t, m, cookie, func(s *Session) {
+		flashes = s.Flashes("notice")
+	})
+	if len(flashes) != 1 || flashes[0] != "saved" {
+		t.Fatalf("Flashes = %v", flashes)
+	}
+	roundTrip(t, m, cookie, func(s *Session) {
+		flashes = s.Flashes("notice")
+	})
+	if len(flashes) != 0 {
+		t.Fatal("一次性消息读取后应当被删除")
+	}
+}
+
+func TestSessionDestroy(t *testing.T) {
+	m := newTestManager(t)
+	_, cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Set("k", "v")
+	})
+	_, cleared := roundTrip(t, m, cookie, func(s *Session) {
+		s.Destroy()
+	})
+	if cleared == nil || cleared.MaxAge >= 0 {
+		t.Fatal("销毁后应当清除 Cookie")
+	}
+	if s, _ := roundTrip(t, m, cookie, nil); len(s.values) != 0 {
+		t.Fatal("销毁后数据仍然存在")
+	}
+}
+
+func TestSessionDestroyUser(t *testing.T) {
+	m := newTestManager(t)
+	var cookies []*http.Cookie
+	for range 2 {
+		_, cookie := roundTrip(t, m, nil, func(s *Session) {
+			s.SetUser("42")
+		})
+		cookies = append(cookies, cookie)
+	}
+	_, other := roundTrip(t, m, nil, func(s *Session) {
+		s.SetUser("7")
+	})
+
+	if err := m.DestroyUser(context.Background(), "42"); err != nil {
+		t.Fatal(err)
+	}
+	for _, cookie := range cookies {
+		if s, _ := roundTrip(t, m, cookie, nil); s.UserID != "" {
+			t.Fatal("用户的 Session 没有被销毁")
+		}
+	}
+	if s, _ := roundTrip(t, m, other, nil); s.UserID != "7" {
+		t.Fatal("其他用户的 Session 不应被销毁")
+	}
+}
@@ -0,0 +1,45 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	dysmsapi20170525 "github.com/alibabacloud-go/dysmsapi-20170525/v5/client"
+	"github.com/alibabacloud-go/tea-utils/v2/service"
+	"github.com/alibabacloud-go/tea/tea"
+	"github.com/ligaolin/goweb/v2/sdk/ali"
+)
+
+// Ali 阿里云短信
+type Ali struct {
+	Sms *ali.AliSms
+}
+
+func NewAli(a *ali.AliSms) *Ali {
+	return &Ali{Sms: a}
+}
+
+// Send 发送短信，templateID 为空时使用 AliSmsConfig.TemplateCodeVerificationCode
+func (a *Ali) Send(ctx context.Context, phone string, templateID string, params map[string]string) error {
+	if templateID == "" {
+		templateID = a.Sms.Config.TemplateCodeVerificationCode
+	}
+	param, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	res, err := a.Sms.Client.SendSmsWithOptions(&dysmsapi20170525.SendSmsRequest{
+		PhoneNumbers:  tea.String(phone),
+		SignName:      tea.String(a.Sms.Config.SignName),
+		TemplateCode:  tea.String(templateID),
+		TemplateParam: tea.String(string(param)),
+	}, &service.RuntimeOptions{})
+	if err != nil {
+		return err
+	}
+	if res.Body != nil && tea.StringValue(res.Body.Code) != "OK" {
+		return fmt.Errorf("阿里云短信发送失败: %s %s", tea.StringValue(res.Body.Code), tea.StringValue(res.Body.Message))
+	}
+	return nil
+}
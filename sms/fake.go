@@ -0,0 +1,66 @@
+package sms
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	Phone      string
+	TemplateID string
+	Params     map[string]string
+	SentAt     time.Time
+}
+
+// Fake 不发送短信，只在内存中记录，用于测试
+type Fake struct {
+	mu       sync.Mutex
+	Messages []Message
+	Err      error // 不为空时 Send 返回该错误且不记录
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Send(ctx context.Context, phone string, templateID string, params map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Messages = append(f.Messages, Message{
+		Phone:      phone,
+		TemplateID: templateID,
+		Params:     maps.Clone(params), // 复制一份，调用方之后修改 params 不影响记录
+		SentAt:     time.Now(),
+	})
+	return nil
+}
+
+// Last 返回发送给 phone 的最后一条短信
+func (f *Fake) Last(phone string) (Message, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.Messages) - 1; i >= 0; i-- {
+		if f.Messages[i].Phone == phone {
+			return f.Messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// LastCode 返回发送给 phone 的最后一条短信中的 code 参数
+func (f *Fake) LastCode(phone string) string {
+	msg, _ := f.Last(phone)
+	return msg.Params["code"]
+}
+
+// Reset 清空已记录的短信
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Messages = nil
+}
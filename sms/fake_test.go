@@ -0,0 +1,45 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFake(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+
+	params := map[string]string{"code": "123456"}
+	if err := f.Send(ctx, "13800000000", "SMS_1", params); err != nil {
+		t.Fatal(err)
+	}
+	// 调用方复用 params 不影响已记录的短信
+	params["code"] = "654321"
+	if code := f.LastCode("13800000000"); code != "123456" {
+		t.Fatalf("LastCode = %q，应当为 123456", code)
+	}
+
+	f.Send(ctx, "13900000000", "SMS_1", map[string]string{"code": "111111"})
+	f.Send(ctx, "13800000000", "SMS_2", map[string]string{"code": "222222"})
+	msg, ok := f.Last("13800000000")
+	if !ok || msg.TemplateID != "SMS_2" || msg.Params["code"] != "222222" {
+		t.Fatalf("Last = %+v, %v", msg, ok)
+	}
+	if _, ok := f.Last("10000000000"); ok {
+		t.Fatal("没有发送过的号码不应当有记录")
+	}
+
+	f.Err = errors.New("发送失败")
+	if err := f.Send(ctx, "13800000000", "SMS_1", nil); !errors.Is(err, f.Err) {
+		t.Fatalf("Send 应当返回 Err，实际为 %v", err)
+	}
+	if len(f.Messages) != 3 {
+		t.Fatalf("发送失败时不应当记录，共 %d 条", len(f.Messages))
+	}
+
+	f.Reset()
+	if len(f.Messages) != 0 {
+		t.Fatal("Reset 后应当清空记录")
+	}
+}
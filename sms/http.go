@@ -0,0 +1,110 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"text/template"
+	"time"
+)
+
+// HTTPConfig 通过 HTTP 接口发送短信的服务商配置。
+// URL 和 Body 为 text/template 模板，可使用 .Phone、.TemplateID、.Params，
+// 以及把任意值编码为 JSON 的 json 函数，例如 {"mobile":{{json .Phone}},"vars":{{json .Params}}}；
+// URL 模板中的 .Phone、.TemplateID、.Params 已经过 URL 转义，可以直接拼接，无需再使用 urlquery
+type HTTPConfig struct {
+	URL         string
+	Method      string            // 默认 POST
+	Header      map[string]string // 附加请求头，例如鉴权信息
+	Body        string
+	ContentType string        // 默认 application/json
+	Timeout     time.Duration // 默认 10 秒
+}
+
+// HTTP 通用的 HTTP 模板短信服务商，响应状态码为 2xx 时视为发送成功
+type HTTP struct {
+	Config *HTTPConfig
+	Client *http.Client
+	url    *template.Template
+	body   *template.Template
+}
+
+func NewHTTP(config *HTTPConfig) (*HTTP, error) {
+	copied := *config
+	config = &copied
+	if config.Method == "" {
+		config.Method = http.MethodPost
+	}
+	if config.ContentType == "" {
+		config.ContentType = "application/json"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	funcs := template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+	u, err := template.New("url").Funcs(funcs).Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("解析短信URL模板失败: %w", err)
+	}
+	body, err := template.New("body").Funcs(funcs).Parse(config.Body)
+	if err != nil {
+		return nil, fmt.Errorf("解析短信请求体模板失败: %w", err)
+	}
+	return &HTTP{
+		Config: config,
+		Client: &http.Client{Timeout: config.Timeout},
+		url:    u,
+		body:   body,
+	}, nil
+}
+
+func (h *HTTP) Send(ctx context.Context, phone string, templateID string, params map[string]string) error {
+	data := map[string]any{
+		"Phone":      phone,
+		"TemplateID": templateID,
+		"Params":     params,
+	}
+	escaped := make(map[string]string, len(params))
+	for k, v := range params {
+		escaped[k] = url.QueryEscape(v)
+	}
+	var u, body bytes.Buffer
+	if err := h.url.Execute(&u, map[string]any{
+		"Phone":      url.QueryEscape(phone),
+		"TemplateID": url.QueryEscape(templateID),
+		"Params":     escaped,
+	}); err != nil {
+		return err
+	}
+	if err := h.body.Execute(&body, data); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, h.Config.Method, u.String(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", h.Config.ContentType)
+	for k, v := range h.Config.Header {
+		req.Header.Set(k, v)
+	}
+	res, err := h.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("短信发送失败: %s %s", res.Status, msg)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPSend(t *testing.T) {
+	var (
+		query  string
+		header http.Header
+		body   map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		header = r.Header
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer srv.Close()
+
+	config := &HTTPConfig{
+		URL:    srv.URL + "/send?mobile={{.Phone}}&tpl={{.TemplateID}}&code={{.Params.code}}",
+		Header: map[string]string{"Authorization": "Bearer token"},
+		Body:   `{"mobile":{{json .Phone}},"vars":{{json .Params}}}`,
+	}
+	h, err := NewHTTP(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Method != "" || config.ContentType != "" {
+		t.Fatalf("NewHTTP 修改了调用方的配置: %+v", config)
+	}
+
+	// 号码中的 + 和 & 在 URL 中需要转义，否则会改变查询参数
+	if err := h.Send(context.Background(), "+86 138&x=1", "SMS 1", map[string]string{"code": "12 34"}); err != nil {
+		t.Fatal(err)
+	}
+	if query != "mobile=%2B86+138%26x%3D1&tpl=SMS+1&code=12+34" {
+		t.Fatalf("查询参数为 %q", query)
+	}
+	if header.Get("Authorization") != "Bearer token" || header.Get("Content-Type") != "application/json" {
+		t.Fatalf("请求头为 %v", header)
+	}
+	// 请求体使用原始值
+	if body["mobile"] != "+86 138&x=1" || body["vars"].(map[string]any)["code"] != "12 34" {
+		t.Fatalf("请求体为 %v", body)
+	}
+}
+
+func TestHTTPSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "invalid template")
+	}))
+	defer srv.Close()
+
+	h, err := NewHTTP(&HTTPConfig{URL: srv.URL, Method: http.MethodGet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.Send(context.Background(), "13800000000", "SMS_1", nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid template") {
+		t.Fatalf("非 2xx 响应应当返回包含响应内容的错误，实际为 %v", err)
+	}
+
+	if _, err := NewHTTP(&HTTPConfig{URL: "{{.Phone"}); err == nil {
+		t.Fatal("模板错误时应当返回错误")
+	}
+}
@@ -0,0 +1,8 @@
+package sms
+
+import "context"
+
+// Sender 短信发送接口，params 为模板参数，例如验证码模板的 {"code": "123456"}
+type Sender interface {
+	Send(ctx context.Context, phone string, templateID string, params map[string]string) error
+}
@@ -85,34 +85,45 @@ func Validator(data any) error {
 
 	t := v.Type()
 	for i := range t.NumField() {
-		fieldValue := v.Field(i)
-		if fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
-			fieldValue = fieldValue.Elem()
+		if err := validate(v.Field(i), t.Field(i).Tag.Get("validate")); err != nil {
+			return err
 		}
-		value := fmt.Sprintf("%v", fieldValue)
+	}
+	return nil
+}
 
-		for tags := range strings.SplitSeq(t.Field(i).Tag.Get("validate"), ";") {
-			tag := strings.Split(tags, ":")
-			if len(tag) < 2 {
-				continue
-			}
-			arr := strings.Split(tag[0], "=")
-			var err error
-			switch arr[0] {
-			case "required":
-				if fieldValue.IsZero() {
-					err = errors.New(tag[1])
-				}
-			case "len", "between", "equal", "in":
-				err = checkComparison(value, arr[1], arr[0], tag[1])
-			case "custom":
-				err = matchRegex(arr[1], value, tag[1])
-			default:
-				err = matchRegex(Rules[arr[0]], value, tag[1])
-			}
-			if err != nil {
-				return err
+// Value 按 validate 标签的规则验证单个值，例如 Value(port, "required:请填写端口;between=1,65535:端口无效")
+func Value(data any, rules string) error {
+	return validate(reflect.ValueOf(data), rules)
+}
+
+func validate(fieldValue reflect.Value, rules string) error {
+	if fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+		fieldValue = fieldValue.Elem()
+	}
+	value := fmt.Sprintf("%v", fieldValue)
+
+	for tags := range strings.SplitSeq(rules, ";") {
+		tag := strings.Split(tags, ":")
+		if len(tag) < 2 {
+			continue
+		}
+		arr := strings.Split(tag[0], "=")
+		var err error
+		switch arr[0] {
+		case "required":
+			if !fieldValue.IsValid() || fieldValue.IsZero() {
+				err = errors.New(tag[1])
 			}
+		case "len", "between", "equal", "in":
+			err = checkComparison(value, arr[1], arr[0], tag[1])
+		case "custom":
+			err = matchRegex(arr[1], value, tag[1])
+		default:
+			err = matchRegex(Rules[arr[0]], value, tag[1])
+		}
+		if err != nil {
+			return err
 		}
 	}
 	return nil